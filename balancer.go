@@ -0,0 +1,314 @@
+package requist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+//=== Multiple base URLs, failover and load balancing
+
+// Strategy defines how a Balancer chooses the next endpoint
+type Strategy int
+
+const (
+	// RoundRobin cycles through healthy endpoints
+	RoundRobin Strategy = iota
+	// Random picks any healthy endpoint
+	Random
+	// LeastInFlight picks the healthy endpoint with fewer requests in progress
+	LeastInFlight
+	// PriorityFailover picks the first healthy endpoint in the order they were given
+	PriorityFailover
+)
+
+const (
+	// defaultMaxFailures is the number of consecutive failures that ejects an endpoint
+	defaultMaxFailures = 3
+	// defaultCooldown is how long an ejected endpoint waits before being re-admitted
+	defaultCooldown = 30 * time.Second
+)
+
+// endpoint holds a base URL and its passive health
+type endpoint struct {
+	base     string
+	failures int
+	inflight int
+	ejected  time.Time
+}
+
+// Balancer spreads requests between several base URLs, ejecting those failing consecutively.
+// It is safe for concurrent use and can be shared by many Requist
+type Balancer struct {
+	// MaxFailures is the number of consecutive failures (errors or 5xx) that ejects an endpoint
+	MaxFailures int
+	// Cooldown is how long an ejected endpoint waits before being re-admitted
+	Cooldown time.Duration
+
+	mutex     sync.Mutex
+	strategy  Strategy
+	endpoints []*endpoint
+	next      int
+	clock     func() time.Time
+}
+
+// NewBalancer returns a Balancer using strategy between bases
+func NewBalancer(strategy Strategy, bases ...string) (*Balancer, error) {
+
+	if len(bases) == 0 {
+		return nil, fmt.Errorf("at least one base URL is required")
+	}
+
+	b := &Balancer{
+		MaxFailures: defaultMaxFailures,
+		Cooldown:    defaultCooldown,
+		strategy:    strategy,
+		clock:       time.Now,
+	}
+	for _, base := range bases {
+		parsed := ParseBaseURL(base)
+		if parsed == "" {
+			return nil, fmt.Errorf("invalid base URL %s", base)
+		}
+		b.endpoints = append(b.endpoints, &endpoint{base: parsed})
+	}
+	return b, nil
+}
+
+// Healthy returns the base URLs currently admitted
+func (b *Balancer) Healthy() []string {
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := b.clock()
+	healthy := make([]string, 0, len(b.endpoints))
+	for _, e := range b.endpoints {
+		if !now.Before(e.ejected) {
+			healthy = append(healthy, e.base)
+		}
+	}
+	return healthy
+}
+
+// acquire chooses the next endpoint not in tried and counts it as in flight.
+// When every candidate is ejected, the one closest to re-admission is used
+func (b *Balancer) acquire(tried map[*endpoint]bool) *endpoint {
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := b.clock()
+	var healthy, ejected []*endpoint
+	for _, e := range b.endpoints {
+		switch {
+		case tried[e]:
+		case now.Before(e.ejected):
+			ejected = append(ejected, e)
+		default:
+			healthy = append(healthy, e)
+		}
+	}
+
+	var chosen *endpoint
+	switch {
+	case len(healthy) == 0 && len(ejected) == 0:
+		return nil
+
+	case len(healthy) == 0:
+		chosen = ejected[0]
+		for _, e := range ejected[1:] {
+			if e.ejected.Before(chosen.ejected) {
+				chosen = e
+			}
+		}
+
+	case b.strategy == Random:
+		chosen = healthy[rand.Intn(len(healthy))]
+
+	case b.strategy == LeastInFlight:
+		chosen = healthy[0]
+		for _, e := range healthy[1:] {
+			if e.inflight < chosen.inflight {
+				chosen = e
+			}
+		}
+
+	case b.strategy == PriorityFailover:
+		chosen = healthy[0]
+
+	default:
+		chosen = healthy[b.next%len(healthy)]
+		b.next++
+	}
+
+	chosen.inflight++
+	return chosen
+}
+
+// release records the outcome of a request sent to e
+func (b *Balancer) release(e *endpoint, failed bool) {
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	e.inflight--
+	if !failed {
+		e.failures = 0
+		return
+	}
+
+	e.failures++
+	max := b.MaxFailures
+	if max <= 0 {
+		max = defaultMaxFailures
+	}
+	if e.failures >= max {
+		e.failures = 0
+		e.ejected = b.clock().Add(b.Cooldown)
+		Logger.Warn("Ejecting endpoint %s until %s", e.base, e.ejected)
+	}
+}
+
+// abandon forgets a request sent to e whose outcome is unknown, it says nothing about its health
+func (b *Balancer) abandon(e *endpoint) {
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	e.inflight--
+}
+
+// size returns the number of endpoints
+func (b *Balancer) size() int {
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return len(b.endpoints)
+}
+
+// first returns the first base URL
+func (b *Balancer) first() string {
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.endpoints[0].base
+}
+
+// unsent tells if err was returned before the request was sent, so it can be sent elsewhere
+func unsent(err error) bool {
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	return errors.Is(err, ErrCircuitOpen)
+}
+
+// rebase returns uri, built on top of origin, pointing to base scheme, host and path prefix
+func rebase(uri, origin, base string) (string, error) {
+
+	target, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	from, err := url.Parse(origin)
+	if err != nil {
+		return "", err
+	}
+	to, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	target.Scheme = to.Scheme
+	target.Host = to.Host
+
+	// Swap origin path prefix with base one
+	path := target.EscapedPath()
+	prefix := strings.TrimSuffix(from.EscapedPath(), "/")
+	if prefix != "" && (path == prefix || strings.HasPrefix(path, prefix+"/")) {
+		path = strings.TrimSuffix(to.EscapedPath(), "/") + strings.TrimPrefix(path, prefix)
+	} else if prefix == "" {
+		path = strings.TrimSuffix(to.EscapedPath(), "/") + path
+	}
+	if target.Path, err = url.PathUnescape(path); err != nil {
+		return "", err
+	}
+	target.RawPath = path
+
+	return target.String(), nil
+}
+
+//=== Requist integration
+
+// SetClientBalancer take balancer param and spread requests between its endpoints, nil disables it.
+// Idempotent requests (GET, HEAD, PUT, DELETE, OPTIONS and TRACE) fail over on errors and 5xx responses,
+// others only when the endpoint couldn't be reached, so they are never sent twice
+func (r *Requist) SetClientBalancer(balancer *Balancer) {
+
+	Logger.Debug("Setting Client Balancer")
+
+	r.balancer = balancer
+	if balancer != nil {
+		r.Base(balancer.first())
+	}
+}
+
+// balance sends uri and payload to the balancer endpoints, failing over on errors and 5xx responses
+func (r *Requist) balance(ctx context.Context, uri string, payload []byte) (*http.Response, error) {
+
+	tried := map[*endpoint]bool{}
+	attempts := r.balancer.size()
+
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+
+		e := r.balancer.acquire(tried)
+		if e == nil {
+			break
+		}
+		tried[e] = true
+
+		target, err := rebase(uri, r.url, e.base)
+		if err != nil {
+			r.balancer.release(e, false)
+			return nil, err
+		}
+		Logger.Debug("Balancing Request to %s", target)
+
+		response, err := r.roundTrip(ctx, target, payload)
+		failed := err != nil || response.StatusCode >= http.StatusInternalServerError
+		if err != nil && ctx.Err() != nil {
+			// The caller gave up (canceled or its Timeout elapsed), the endpoint may be healthy
+			r.balancer.abandon(e)
+		} else {
+			r.balancer.release(e, failed)
+		}
+
+		if !failed || attempt == attempts || ctx.Err() != nil || !(idempotentMethods[r.method] || unsent(err)) {
+			return response, err
+		}
+
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		// Discard server errors while there are endpoints left to try
+		_, _ = io.Copy(ioutil.Discard, response.Body)
+		_ = response.Body.Close()
+		lastErr = fmt.Errorf("%s answered %d", e.base, response.StatusCode)
+	}
+	return nil, lastErr
+}
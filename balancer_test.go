@@ -0,0 +1,292 @@
+package requist
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// MockNamedServer answers with its name and the given status code
+func MockNamedServer(name string, status int) *httptest.Server {
+
+	return httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", JSONContentType)
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"result": "` + name + `"}`))
+		}),
+	)
+}
+
+func TestNewBalancer(t *testing.T) {
+
+	t.Run("fail without bases", func(t *testing.T) {
+
+		balancer, err := NewBalancer(RoundRobin)
+
+		// our data is correct?
+		assert.NotNil(t, err)
+		assert.Nil(t, balancer)
+	})
+
+	t.Run("fail with invalid base", func(t *testing.T) {
+
+		balancer, err := NewBalancer(RoundRobin, "http://live.apitest.org", "file:///tmp")
+
+		// our data is correct?
+		assert.NotNil(t, err)
+		assert.Nil(t, balancer)
+	})
+}
+
+func TestRequist_SetClientBalancer(t *testing.T) {
+
+	// We create Mock Servers
+	one := MockNamedServer("one", http.StatusOK)
+	defer one.Close()
+	two := MockNamedServer("two", http.StatusOK)
+	defer two.Close()
+	broken := MockNamedServer("broken", http.StatusServiceUnavailable)
+	defer broken.Close()
+
+	t.Run("spread requests with round robin", func(t *testing.T) {
+
+		balancer, err := NewBalancer(RoundRobin, one.URL, two.URL)
+		assert.Nil(t, err)
+
+		// We create our requist Client
+		emptyClient := New(one.URL)
+		emptyClient.SetClientBalancer(balancer)
+		emptyClient.Accept(JSONContentType)
+
+		var results []string
+		for i := 0; i < 4; i++ {
+			success := &GenericResponse{}
+			_, err := emptyClient.Get("/", success, nil)
+			assert.Nil(t, err)
+			results = append(results, success.Result)
+		}
+
+		// our data is correct?
+		assert.EqualValues(t, []string{"one", "two", "one", "two"}, results)
+	})
+
+	t.Run("fail over server errors and ejects endpoint", func(t *testing.T) {
+
+		balancer, err := NewBalancer(PriorityFailover, broken.URL, one.URL)
+		assert.Nil(t, err)
+		balancer.MaxFailures = 2
+
+		// We create our requist Client
+		emptyClient := New(one.URL)
+		emptyClient.SetClientBalancer(balancer)
+		emptyClient.Accept(JSONContentType)
+
+		for i := 0; i < 2; i++ {
+			success := &GenericResponse{}
+			_, err := emptyClient.Get("/", success, nil)
+
+			// our data is correct?
+			assert.Nil(t, err)
+			assert.EqualValues(t, "one", success.Result)
+		}
+
+		// broken endpoint must be ejected
+		assert.EqualValues(t, []string{ParseBaseURL(one.URL)}, balancer.Healthy())
+	})
+
+	t.Run("fail over unreachable endpoints", func(t *testing.T) {
+
+		down := MockNamedServer("down", http.StatusOK)
+		down.Close()
+
+		balancer, err := NewBalancer(PriorityFailover, down.URL, two.URL)
+		assert.Nil(t, err)
+
+		// We create our requist Client
+		emptyClient := New(two.URL)
+		emptyClient.SetClientBalancer(balancer)
+		emptyClient.Accept(JSONContentType)
+
+		success := &GenericResponse{}
+		_, err = emptyClient.Get("/", success, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.EqualValues(t, "two", success.Result)
+	})
+
+	t.Run("return last server error when every endpoint fails", func(t *testing.T) {
+
+		balancer, err := NewBalancer(RoundRobin, broken.URL)
+		assert.Nil(t, err)
+
+		// We create our requist Client
+		emptyClient := New(broken.URL)
+		emptyClient.SetClientBalancer(balancer)
+
+		_, err = emptyClient.Get("/", nil, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.EqualValues(t, http.StatusServiceUnavailable, emptyClient.StatusCode())
+	})
+
+	t.Run("don't fail over non idempotent requests once sent", func(t *testing.T) {
+
+		orders := 0
+
+		// We create a Mock Server counting orders
+		other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			orders++
+			w.WriteHeader(http.StatusCreated)
+		}))
+		defer other.Close()
+
+		balancer, err := NewBalancer(PriorityFailover, broken.URL, other.URL)
+		assert.Nil(t, err)
+
+		// We create our requist Client
+		emptyClient := New(broken.URL)
+		emptyClient.SetClientBalancer(balancer)
+
+		_, err = emptyClient.BodyAsJSON(&GenericResponse{Result: "order"}).Post("/orders", nil, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.EqualValues(t, http.StatusServiceUnavailable, emptyClient.StatusCode())
+		assert.Equal(t, 0, orders)
+	})
+
+	t.Run("fail over non idempotent requests to unreachable endpoints", func(t *testing.T) {
+
+		down := MockNamedServer("down", http.StatusOK)
+		down.Close()
+
+		balancer, err := NewBalancer(PriorityFailover, down.URL, two.URL)
+		assert.Nil(t, err)
+
+		// We create our requist Client
+		emptyClient := New(two.URL)
+		emptyClient.SetClientBalancer(balancer)
+		emptyClient.Accept(JSONContentType)
+
+		success := &GenericResponse{}
+		_, err = emptyClient.BodyAsJSON(&GenericResponse{Result: "order"}).Post("/orders", success, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.EqualValues(t, "two", success.Result)
+	})
+
+	t.Run("don't eject endpoints when the caller gives up", func(t *testing.T) {
+
+		// We create a Mock Server answering slowly
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-time.After(100 * time.Millisecond):
+			case <-r.Context().Done():
+			}
+		}))
+		defer slow.Close()
+
+		balancer, err := NewBalancer(RoundRobin, slow.URL)
+		assert.Nil(t, err)
+		balancer.MaxFailures = 1
+
+		// We create our requist Client
+		emptyClient := New(slow.URL)
+		emptyClient.SetClientBalancer(balancer)
+
+		for i := 0; i < 3; i++ {
+			_, err = emptyClient.Timeout(10*time.Millisecond).Get("/", nil, nil)
+			assert.True(t, errors.Is(err, ErrTimeout))
+		}
+
+		// our data is correct?
+		assert.EqualValues(t, []string{ParseBaseURL(slow.URL)}, balancer.Healthy())
+	})
+}
+
+func TestBalancer_acquire(t *testing.T) {
+
+	now := time.Now()
+
+	t.Run("pick least in flight", func(t *testing.T) {
+
+		balancer, _ := NewBalancer(LeastInFlight, "http://one.apitest.org", "http://two.apitest.org")
+
+		first := balancer.acquire(nil)
+		second := balancer.acquire(nil)
+
+		// our data is correct?
+		assert.NotEqual(t, first, second)
+
+		balancer.release(first, false)
+		assert.EqualValues(t, first, balancer.acquire(nil))
+	})
+
+	t.Run("re-admit endpoint after cooldown", func(t *testing.T) {
+
+		balancer, _ := NewBalancer(PriorityFailover, "http://one.apitest.org", "http://two.apitest.org")
+		balancer.MaxFailures = 1
+		balancer.Cooldown = time.Minute
+		balancer.clock = func() time.Time { return now }
+
+		first := balancer.acquire(nil)
+		balancer.release(first, true)
+
+		// our data is correct?
+		assert.EqualValues(t, []string{"http://two.apitest.org"}, balancer.Healthy())
+		assert.EqualValues(t, "http://two.apitest.org", balancer.acquire(nil).base)
+
+		balancer.clock = func() time.Time { return now.Add(time.Minute) }
+
+		// our data is correct?
+		assert.Len(t, balancer.Healthy(), 2)
+		assert.EqualValues(t, first, balancer.acquire(nil))
+	})
+
+	t.Run("use ejected endpoint as last resort", func(t *testing.T) {
+
+		balancer, _ := NewBalancer(RoundRobin, "http://one.apitest.org")
+		balancer.MaxFailures = 1
+
+		first := balancer.acquire(nil)
+		balancer.release(first, true)
+
+		// our data is correct?
+		assert.Empty(t, balancer.Healthy())
+		assert.EqualValues(t, first, balancer.acquire(nil))
+	})
+}
+
+func TestRebase(t *testing.T) {
+
+	tests := []struct {
+		uri      string
+		origin   string
+		base     string
+		expected string
+	}{
+		{"http://one/users?page=2", "http://one", "https://two:8443", "https://two:8443/users?page=2"},
+		{"http://one/api/users", "http://one/api", "http://two/v2/api", "http://two/v2/api/users"},
+		{"http://one/api", "http://one/api", "http://two/v2", "http://two/v2"},
+		{"http://one/users", "http://one", "http://two/api/", "http://two/api/users"},
+		{"http://one/api/a%2Fb", "http://one/api", "http://two", "http://two/a%2Fb"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.uri+" to "+test.base, func(t *testing.T) {
+
+			result, err := rebase(test.uri, test.origin, test.base)
+
+			// our data is correct?
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, result)
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package requist
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+//=== Circuit breaker per host
+
+// BreakerState is the state of a host circuit
+type BreakerState int
+
+const (
+	// StateClosed lets every request through
+	StateClosed BreakerState = iota
+	// StateOpen rejects every request until OpenDuration elapses
+	StateOpen
+	// StateHalfOpen lets a limited number of probes through to decide if host recovered
+	StateHalfOpen
+)
+
+// String implements fmt.Stringer interface
+func (s BreakerState) String() string {
+
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+const (
+	// defaultBreakerFailures is the number of consecutive failures that opens a circuit
+	defaultBreakerFailures = 5
+	// defaultOpenDuration is how long a circuit stays open
+	defaultOpenDuration = 30 * time.Second
+	// defaultBreakerWindow is the interval where failure ratio is computed
+	defaultBreakerWindow = time.Minute
+)
+
+// ErrCircuitOpen is returned while a host circuit rejects requests
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitOpenError describes a request rejected by an open circuit
+type CircuitOpenError struct {
+	// Host whose circuit is open
+	Host string
+	// Until is when the circuit will let probes through
+	Until time.Time
+}
+
+// Error implements error interface
+func (e *CircuitOpenError) Error() string {
+
+	return fmt.Sprintf("%s for %s until %s", ErrCircuitOpen, e.Host, e.Until.Format(time.RFC3339))
+}
+
+// Unwrap allows errors.Is(err, ErrCircuitOpen)
+func (e *CircuitOpenError) Unwrap() error {
+
+	return ErrCircuitOpen
+}
+
+// circuit holds the state of a single host, generation changes on every transition
+type circuit struct {
+	state       BreakerState
+	generation  uint64
+	consecutive int
+	requests    int
+	failures    int
+	window      time.Time
+	opened      time.Time
+	probes      int
+	successes   int
+}
+
+// CircuitBreaker stops sending requests to hosts that keep failing.
+// It is safe for concurrent use and can be shared by many Requist
+type CircuitBreaker struct {
+	// ConsecutiveFailures opens the circuit after that many failures in a row, zero disables it
+	ConsecutiveFailures int
+	// FailureRatio opens the circuit when failures/requests in Window reaches it, zero disables it
+	FailureRatio float64
+	// MinRequests is the number of requests needed in Window before FailureRatio applies
+	MinRequests int
+	// Window is the fixed interval where FailureRatio is computed
+	Window time.Duration
+	// OpenDuration is how long the circuit rejects requests before probing the host
+	OpenDuration time.Duration
+	// HalfOpenProbes is the number of concurrent probes allowed, and of successes needed to close again
+	HalfOpenProbes int
+	// IsFailure decides if a request failed, by default errors and 5xx responses
+	IsFailure func(response *http.Response, err error) bool
+	// OnStateChange is called every time a host circuit changes its state
+	OnStateChange func(host string, from, to BreakerState)
+
+	mutex    sync.Mutex
+	circuits map[string]*circuit
+	pending  []func()
+	clock    func() time.Time
+}
+
+// NewCircuitBreaker returns a CircuitBreaker opening after 5 consecutive failures for 30 seconds
+func NewCircuitBreaker() *CircuitBreaker {
+
+	return &CircuitBreaker{
+		ConsecutiveFailures: defaultBreakerFailures,
+		Window:              defaultBreakerWindow,
+		OpenDuration:        defaultOpenDuration,
+		HalfOpenProbes:      1,
+		circuits:            map[string]*circuit{},
+		clock:               time.Now,
+	}
+}
+
+// State returns the current state of host circuit
+func (b *CircuitBreaker) State(host string) BreakerState {
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	c := b.circuit(host)
+	if c.state == StateOpen && !b.clock().Before(c.opened.Add(b.OpenDuration)) {
+		return StateHalfOpen
+	}
+	return c.state
+}
+
+// breakerToken is handed to a request allowed through a circuit, so its outcome is recorded only
+// against the circuit state that let it through
+type breakerToken struct {
+	generation uint64
+	probe      bool
+}
+
+// allow checks if a request to host can be sent, returning the token to record its outcome with
+func (b *CircuitBreaker) allow(host string) (breakerToken, error) {
+
+	defer b.notify()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	c := b.circuit(host)
+	now := b.clock()
+
+	if c.state == StateOpen {
+		until := c.opened.Add(b.OpenDuration)
+		if now.Before(until) {
+			return breakerToken{}, &CircuitOpenError{Host: host, Until: until}
+		}
+		b.transition(host, c, StateHalfOpen)
+	}
+
+	if c.state == StateHalfOpen {
+		if c.probes >= b.probes() {
+			return breakerToken{}, &CircuitOpenError{Host: host, Until: now}
+		}
+		c.probes++
+	}
+	return breakerToken{generation: c.generation, probe: c.state == StateHalfOpen}, nil
+}
+
+// record stores the outcome of a request to host allowed with token. Outcomes of requests allowed
+// before the circuit last changed its state are ignored, they say nothing about the current one
+func (b *CircuitBreaker) record(host string, token breakerToken, response *http.Response, err error) {
+
+	failed := err != nil || response.StatusCode >= http.StatusInternalServerError
+	if b.IsFailure != nil {
+		failed = b.IsFailure(response, err)
+	}
+
+	defer b.notify()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	c := b.circuit(host)
+	now := b.clock()
+
+	if token.generation != c.generation {
+		return
+	}
+
+	switch c.state {
+	case StateHalfOpen:
+		c.probes--
+		if failed {
+			b.transition(host, c, StateOpen)
+			return
+		}
+		c.successes++
+		if c.successes >= b.probes() {
+			b.transition(host, c, StateClosed)
+		}
+
+	case StateClosed:
+		window := b.Window
+		if window <= 0 {
+			window = defaultBreakerWindow
+		}
+		if now.Sub(c.window) >= window {
+			c.window, c.requests, c.failures = now, 0, 0
+		}
+
+		c.requests++
+		if !failed {
+			c.consecutive = 0
+			return
+		}
+		c.failures++
+		c.consecutive++
+
+		if b.ConsecutiveFailures > 0 && c.consecutive >= b.ConsecutiveFailures {
+			b.transition(host, c, StateOpen)
+			return
+		}
+		if b.FailureRatio > 0 && c.requests >= b.MinRequests && float64(c.failures)/float64(c.requests) >= b.FailureRatio {
+			b.transition(host, c, StateOpen)
+		}
+	}
+}
+
+// abandon forgets a request to host allowed with token whose outcome is unknown, freeing its half-open probe
+func (b *CircuitBreaker) abandon(host string, token breakerToken) {
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if c := b.circuit(host); token.probe && token.generation == c.generation && c.probes > 0 {
+		c.probes--
+	}
+}
+
+// circuit returns host circuit, creating it if missing. Must be called holding the mutex
+func (b *CircuitBreaker) circuit(host string) *circuit {
+
+	if b.circuits == nil {
+		b.circuits = map[string]*circuit{}
+	}
+	if b.clock == nil {
+		b.clock = time.Now
+	}
+
+	c, ok := b.circuits[host]
+	if !ok {
+		c = &circuit{window: b.clock()}
+		b.circuits[host] = c
+	}
+	return c
+}
+
+// probes returns the number of half-open probes
+func (b *CircuitBreaker) probes() int {
+
+	if b.HalfOpenProbes <= 0 {
+		return 1
+	}
+	return b.HalfOpenProbes
+}
+
+// transition moves c into state, resetting its counters. Must be called holding the mutex
+func (b *CircuitBreaker) transition(host string, c *circuit, state BreakerState) {
+
+	from := c.state
+	now := b.clock()
+
+	*c = circuit{state: state, generation: c.generation + 1, window: now}
+	if state == StateOpen {
+		c.opened = now
+	}
+
+	Logger.Warn("Circuit for %s changed from %s to %s", host, from, state)
+
+	if b.OnStateChange != nil {
+		b.pending = append(b.pending, func() { b.OnStateChange(host, from, state) })
+	}
+}
+
+// notify runs pending state change callbacks, outside the mutex so they can query the breaker
+func (b *CircuitBreaker) notify() {
+
+	b.mutex.Lock()
+	pending := b.pending
+	b.pending = nil
+	b.mutex.Unlock()
+
+	for _, callback := range pending {
+		callback()
+	}
+}
+
+//=== Requist integration
+
+// SetClientCircuitBreaker take breaker param and use it to guard every host, nil disables it
+func (r *Requist) SetClientCircuitBreaker(breaker *CircuitBreaker) {
+
+	Logger.Debug("Setting Client Circuit Breaker")
+
+	r.breaker = breaker
+}
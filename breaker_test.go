@@ -0,0 +1,180 @@
+package requist
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// MockFlakyServer answers with the status stored in status
+func MockFlakyServer(status *int, hits *int) *httptest.Server {
+
+	return httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*hits++
+			w.WriteHeader(*status)
+		}),
+	)
+}
+
+func TestRequist_SetClientCircuitBreaker(t *testing.T) {
+
+	status, hits := http.StatusInternalServerError, 0
+
+	// We create a Mock Server
+	server := MockFlakyServer(&status, &hits)
+	defer server.Close()
+
+	host := server.Listener.Addr().String()
+	now := time.Now()
+
+	var changes []string
+	breaker := NewCircuitBreaker()
+	breaker.ConsecutiveFailures = 2
+	breaker.OpenDuration = time.Minute
+	breaker.clock = func() time.Time { return now }
+	breaker.OnStateChange = func(host string, from, to BreakerState) {
+		changes = append(changes, from.String()+">"+to.String())
+	}
+
+	// We create our requist Client
+	emptyClient := New(server.URL)
+	emptyClient.SetClientCircuitBreaker(breaker)
+
+	t.Run("open after consecutive failures", func(t *testing.T) {
+
+		for i := 0; i < 2; i++ {
+			_, err := emptyClient.Get("/", nil, nil)
+			assert.Nil(t, err)
+		}
+
+		// our data is correct?
+		assert.EqualValues(t, StateOpen, breaker.State(host))
+		assert.EqualValues(t, []string{"closed>open"}, changes)
+	})
+
+	t.Run("reject requests while open", func(t *testing.T) {
+
+		_, err := emptyClient.Get("/", nil, nil)
+
+		// our data is correct?
+		assert.True(t, errors.Is(err, ErrCircuitOpen))
+
+		var openError *CircuitOpenError
+		assert.True(t, errors.As(err, &openError))
+		assert.EqualValues(t, host, openError.Host)
+		assert.EqualValues(t, 2, hits)
+	})
+
+	t.Run("reopen when half-open probe fails", func(t *testing.T) {
+
+		now = now.Add(time.Minute)
+		assert.EqualValues(t, StateHalfOpen, breaker.State(host))
+
+		_, err := emptyClient.Get("/", nil, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.EqualValues(t, StateOpen, breaker.State(host))
+		assert.EqualValues(t, []string{"closed>open", "open>half-open", "half-open>open"}, changes)
+	})
+
+	t.Run("close when half-open probe succeeds", func(t *testing.T) {
+
+		now = now.Add(time.Minute)
+		status = http.StatusOK
+
+		_, err := emptyClient.Get("/", nil, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.EqualValues(t, StateClosed, breaker.State(host))
+		assert.EqualValues(t, "half-open>closed", changes[len(changes)-1])
+	})
+}
+
+func TestCircuitBreaker_FailureRatio(t *testing.T) {
+
+	breaker := NewCircuitBreaker()
+	breaker.ConsecutiveFailures = 0
+	breaker.FailureRatio = 0.5
+	breaker.MinRequests = 4
+
+	success := &http.Response{StatusCode: http.StatusOK}
+	failure := errors.New("connection refused")
+
+	host := "live.apitest.org"
+	outcomes := []error{nil, failure, nil}
+	for _, outcome := range outcomes {
+		token, err := breaker.allow(host)
+		assert.Nil(t, err)
+		if outcome != nil {
+			breaker.record(host, token, nil, outcome)
+		} else {
+			breaker.record(host, token, success, nil)
+		}
+	}
+
+	// not enough requests yet
+	assert.EqualValues(t, StateClosed, breaker.State(host))
+
+	token, err := breaker.allow(host)
+	assert.Nil(t, err)
+	breaker.record(host, token, nil, failure)
+
+	// 2 failures out of 4 requests
+	assert.EqualValues(t, StateOpen, breaker.State(host))
+	_, err = breaker.allow(host)
+	assert.NotNil(t, err)
+
+	// other hosts are not affected
+	assert.EqualValues(t, StateClosed, breaker.State("other.apitest.org"))
+}
+
+func TestCircuitBreaker_LateOutcomes(t *testing.T) {
+
+	now := time.Now()
+	breaker := NewCircuitBreaker()
+	breaker.ConsecutiveFailures = 1
+	breaker.OpenDuration = time.Minute
+	breaker.clock = func() time.Time { return now }
+
+	success := &http.Response{StatusCode: http.StatusOK}
+	failure := errors.New("connection refused")
+	host := "live.apitest.org"
+
+	// slow is sent while closed, and answers once the circuit is half-open
+	slow, err := breaker.allow(host)
+	assert.Nil(t, err)
+	other, err := breaker.allow(host)
+	assert.Nil(t, err)
+	breaker.record(host, other, nil, failure)
+	assert.EqualValues(t, StateOpen, breaker.State(host))
+
+	now = now.Add(time.Minute)
+	probe, err := breaker.allow(host)
+	assert.Nil(t, err)
+
+	t.Run("ignore outcomes of requests sent before", func(t *testing.T) {
+
+		breaker.record(host, slow, success, nil)
+		breaker.abandon(host, slow)
+
+		// our data is correct?
+		assert.EqualValues(t, StateHalfOpen, breaker.State(host))
+		_, err := breaker.allow(host)
+		assert.True(t, errors.Is(err, ErrCircuitOpen))
+	})
+
+	t.Run("decide with probes only", func(t *testing.T) {
+
+		breaker.record(host, probe, success, nil)
+		breaker.record(host, probe, nil, failure)
+
+		// our data is correct?
+		assert.EqualValues(t, StateClosed, breaker.State(host))
+	})
+}
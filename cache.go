@@ -0,0 +1,548 @@
+package requist
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//=== HTTP caching (RFC 9111)
+
+// CacheStatus tells how a response was obtained when a Cache is in use
+type CacheStatus int
+
+const (
+	// CacheMiss means the response came from the server
+	CacheMiss CacheStatus = iota
+	// CacheHit means a fresh stored response was used
+	CacheHit
+	// CacheRevalidated means a stored response was confirmed by the server with 304 Not Modified
+	CacheRevalidated
+	// CacheStale means a stale stored response was used, by stale-while-revalidate or stale-if-error
+	CacheStale
+)
+
+// String implements fmt.Stringer interface
+func (s CacheStatus) String() string {
+
+	switch s {
+	case CacheMiss:
+		return "miss"
+	case CacheHit:
+		return "hit"
+	case CacheRevalidated:
+		return "revalidated"
+	case CacheStale:
+		return "stale"
+	}
+	return "unknown"
+}
+
+// heuristicFraction is the fraction of Last-Modified age used as freshness when no explicit one is given
+const heuristicFraction = 10
+
+// safeMethods are the methods that never invalidate stored responses
+var safeMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// cacheableByDefault are the status codes that can be stored using heuristic freshness
+var cacheableByDefault = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusPermanentRedirect:    true,
+	http.StatusNotFound:             true,
+	http.StatusMethodNotAllowed:     true,
+	http.StatusGone:                 true,
+	http.StatusRequestURITooLong:    true,
+	http.StatusNotImplemented:       true,
+}
+
+// cacheControl holds Cache-Control directives, lowercased and unquoted
+type cacheControl map[string]string
+
+// parseCacheControl returns the directives found in every Cache-Control header
+func parseCacheControl(header http.Header) cacheControl {
+
+	directives := cacheControl{}
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			pair := strings.SplitN(strings.TrimSpace(directive), "=", 2)
+			if pair[0] == "" {
+				continue
+			}
+			name := strings.ToLower(pair[0])
+			if len(pair) == 2 {
+				directives[name] = strings.Trim(pair[1], `"`)
+			} else {
+				directives[name] = ""
+			}
+		}
+	}
+	return directives
+}
+
+// has tells if directive is present
+func (c cacheControl) has(directive string) bool {
+
+	_, ok := c[directive]
+	return ok
+}
+
+// seconds returns directive value as a duration
+func (c cacheControl) seconds(directive string) (time.Duration, bool) {
+
+	value, ok := c[directive]
+	if !ok {
+		return 0, false
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// date returns the Date header of a stored response, or when it was received if missing
+func (c *CachedResponse) date() time.Time {
+
+	if date, err := http.ParseTime(c.Header.Get("Date")); err == nil {
+		return date
+	}
+	return c.ResponseTime
+}
+
+// age returns the current age of a stored response (RFC 9111 section 4.2.3)
+func (c *CachedResponse) age(now time.Time) time.Duration {
+
+	apparent := c.ResponseTime.Sub(c.date())
+	if apparent < 0 {
+		apparent = 0
+	}
+
+	var value time.Duration
+	if seconds, err := strconv.ParseInt(c.Header.Get("Age"), 10, 64); err == nil && seconds > 0 {
+		value = time.Duration(seconds) * time.Second
+	}
+	corrected := value + c.ResponseTime.Sub(c.RequestTime)
+
+	initial := apparent
+	if corrected > initial {
+		initial = corrected
+	}
+	return initial + now.Sub(c.ResponseTime)
+}
+
+// lifetime returns the freshness lifetime of a stored response (RFC 9111 section 4.2.1)
+func (c *CachedResponse) lifetime(shared bool) time.Duration {
+
+	control := parseCacheControl(c.Header)
+	if shared {
+		if maxAge, ok := control.seconds("s-maxage"); ok {
+			return maxAge
+		}
+	}
+	if maxAge, ok := control.seconds("max-age"); ok {
+		return maxAge
+	}
+	if expires := c.Header.Get("Expires"); expires != "" {
+		// Invalid dates, like 0, mean already expired
+		date, err := http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+		return date.Sub(c.date())
+	}
+	if cacheableByDefault[c.StatusCode] {
+		if modified, err := http.ParseTime(c.Header.Get("Last-Modified")); err == nil {
+			if heuristic := c.date().Sub(modified) / heuristicFraction; heuristic > 0 {
+				return heuristic
+			}
+		}
+	}
+	return 0
+}
+
+// within tells if a stale response age is still inside the window granted by directive
+func (c *CachedResponse) within(directive string, now time.Time, shared bool) bool {
+
+	control := parseCacheControl(c.Header)
+	if control.has("must-revalidate") || control.has("no-cache") || (shared && control.has("proxy-revalidate")) {
+		return false
+	}
+	window, ok := control.seconds(directive)
+	return ok && c.age(now) < c.lifetime(shared)+window
+}
+
+// matches tells if header carries the same values for the request headers nominated by Vary
+func (c *CachedResponse) matches(header http.Header) bool {
+
+	for name, values := range c.Vary {
+		if strings.Join(header.Values(name), ", ") != strings.Join(values, ", ") {
+			return false
+		}
+	}
+	return true
+}
+
+// conditions adds the validators of a stored response to header
+func (c *CachedResponse) conditions(header http.Header) {
+
+	if etag := c.Header.Get("ETag"); etag != "" {
+		header.Set("If-None-Match", etag)
+	}
+	if modified := c.Header.Get("Last-Modified"); modified != "" {
+		header.Set("If-Modified-Since", modified)
+	}
+}
+
+// response returns a new http.Response from a stored response
+func (c *CachedResponse) response(now time.Time) *http.Response {
+
+	header := c.Header.Clone()
+	header.Set("Age", strconv.FormatInt(int64(c.age(now)/time.Second), 10))
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", c.StatusCode, http.StatusText(c.StatusCode)),
+		StatusCode:    c.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(c.Body)),
+		ContentLength: int64(len(c.Body)),
+	}
+}
+
+//=== Cache
+
+// Cache stores GET responses following their Cache-Control, Expires and Vary headers, and revalidates
+// them with ETag and Last-Modified. It is safe for concurrent use.
+// Responses are stored by URL and Vary headers only, credentials aside, so a private Cache must only be used
+// by Requist sending the same credentials (Authorization, cookies), ie: one Cache per user. A Shared one skips
+// private and authorized responses, and can be used across credentials
+type Cache struct {
+	// Store keeps the responses
+	Store CacheStore
+	// Shared makes the cache behave as a shared one, skipping private responses and honoring s-maxage
+	Shared bool
+
+	mutex        sync.Mutex
+	revalidating map[string]bool
+	clock        func() time.Time
+}
+
+// NewCache returns a private Cache keeping responses in store, not to be used across credentials
+func NewCache(store CacheStore) *Cache {
+
+	return &Cache{
+		Store:        store,
+		revalidating: map[string]bool{},
+		clock:        time.Now,
+	}
+}
+
+// now returns current time
+func (c *Cache) now() time.Time {
+
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clock()
+}
+
+// key identifies a request in the store
+func (c *Cache) key(uri string) string {
+
+	return http.MethodGet + " " + uri
+}
+
+// lookup returns the stored response for uri matching header
+func (c *Cache) lookup(uri string, header http.Header) (*CachedResponse, bool) {
+
+	stored, ok := c.Store.Get(c.key(uri))
+	if !ok || !stored.matches(header) {
+		return nil, false
+	}
+	return stored, true
+}
+
+// invalidate removes the stored response for uri
+func (c *Cache) invalidate(uri string) {
+
+	c.Store.Delete(c.key(uri))
+}
+
+// storable tells if response to a request with header can be stored (RFC 9111 section 3)
+func (c *Cache) storable(response *http.Response, header http.Header) bool {
+
+	control := parseCacheControl(response.Header)
+	request := parseCacheControl(header)
+
+	switch {
+	case request.has("no-store") || control.has("no-store"):
+		return false
+	case c.Shared && control.has("private"):
+		return false
+	case c.Shared && header.Get("Authorization") != "" &&
+		!control.has("public") && !control.has("s-maxage") && !control.has("must-revalidate"):
+		return false
+	case strings.TrimSpace(response.Header.Get("Vary")) == "*":
+		return false
+	}
+
+	explicit := control.has("max-age") || control.has("public") || response.Header.Get("Expires") != "" ||
+		(c.Shared && control.has("s-maxage"))
+	if !explicit && !cacheableByDefault[response.StatusCode] {
+		return false
+	}
+
+	// Responses without freshness nor validators would never be used
+	validators := response.Header.Get("ETag") != "" || response.Header.Get("Last-Modified") != ""
+	return explicit || validators
+}
+
+// store keeps response to a request with header if allowed, returning it with a fresh body
+func (c *Cache) store(uri string, header http.Header, response *http.Response, requested time.Time) (*http.Response, error) {
+
+	if !c.storable(response, header) {
+		return response, nil
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	stored := &CachedResponse{
+		Key:          c.key(uri),
+		StatusCode:   response.StatusCode,
+		Header:       response.Header.Clone(),
+		Body:         body,
+		RequestTime:  requested,
+		ResponseTime: c.now(),
+	}
+	for _, value := range response.Header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = http.CanonicalHeaderKey(strings.TrimSpace(name)); name != "" {
+				if stored.Vary == nil {
+					stored.Vary = http.Header{}
+				}
+				stored.Vary[name] = header.Values(name)
+			}
+		}
+	}
+
+	Logger.Debug("Storing Response for %s", uri)
+	c.Store.Set(stored.Key, stored)
+	return response, nil
+}
+
+// refresh updates a stored response with the headers of a 304 Not Modified (RFC 9111 section 4.3.4)
+func (c *Cache) refresh(stored *CachedResponse, response *http.Response, requested time.Time) *CachedResponse {
+
+	refreshed := *stored
+	refreshed.Header = stored.Header.Clone()
+	for name, values := range response.Header {
+		if name == "Content-Length" {
+			continue
+		}
+		refreshed.Header[name] = values
+	}
+	refreshed.RequestTime = requested
+	refreshed.ResponseTime = c.now()
+
+	c.Store.Set(refreshed.Key, &refreshed)
+	return &refreshed
+}
+
+// begin marks uri as being revalidated in background, false if it already is
+func (c *Cache) begin(uri string) bool {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.revalidating == nil {
+		c.revalidating = map[string]bool{}
+	}
+	if c.revalidating[uri] {
+		return false
+	}
+	c.revalidating[uri] = true
+	return true
+}
+
+// end marks uri background revalidation as finished
+func (c *Cache) end(uri string) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.revalidating, uri)
+}
+
+//=== Request scoped headers
+
+// requestHeadersKey is the context key holding headers added to a single request
+type requestHeadersKey struct{}
+
+// withRequestHeaders returns a copy of ctx carrying header, on top of those already carried,
+// to be added to the request sent
+func withRequestHeaders(ctx context.Context, header http.Header) context.Context {
+
+	merged := requestHeaders(ctx).Clone()
+	if merged == nil {
+		merged = http.Header{}
+	}
+	for key, values := range header {
+		merged[key] = values
+	}
+	return context.WithValue(ctx, requestHeadersKey{}, merged)
+}
+
+// requestHeaders returns the headers carried by ctx, nil if none
+func requestHeaders(ctx context.Context) http.Header {
+
+	header, _ := ctx.Value(requestHeadersKey{}).(http.Header)
+	return header
+}
+
+//=== Requist integration
+
+// SetClientCache take cache param and use it for GET requests, nil disables it
+func (r *Requist) SetClientCache(cache *Cache) {
+
+	Logger.Debug("Setting Client Cache (%T)", cache)
+
+	r.cache = cache
+}
+
+// CacheStatus return how last response was obtained from the cache
+func (r *Requist) CacheStatus() CacheStatus {
+
+	return r.cacheStatus
+}
+
+// cached answers uri from the cache when possible, calling fetch otherwise
+func (r *Requist) cached(ctx context.Context, uri string, fetch func(ctx context.Context) (*http.Response, error)) (*http.Response, CacheStatus, error) {
+
+	cache := r.cache
+	header := r.header.Clone()
+	for key, values := range requestHeaders(ctx) {
+		header[key] = values
+	}
+	request := parseCacheControl(header)
+
+	// Requests carrying their own preconditions are answered by the server only
+	if request.has("no-store") || hasPreconditions(header) {
+		response, err := fetch(ctx)
+		return response, CacheMiss, err
+	}
+
+	now := cache.now()
+	stored, found := cache.lookup(uri, header)
+	if found {
+		control := parseCacheControl(stored.Header)
+		age, lifetime := stored.age(now), stored.lifetime(cache.Shared)
+
+		fresh := age < lifetime && !control.has("no-cache") && !request.has("no-cache")
+		if maxAge, ok := request.seconds("max-age"); ok && age > maxAge {
+			fresh = false
+		}
+		if fresh {
+			Logger.Debug("Cache hit for %s", uri)
+			return stored.response(now), CacheHit, nil
+		}
+
+		if !request.has("no-cache") && stored.within("stale-while-revalidate", now, cache.Shared) {
+			Logger.Debug("Cache stale for %s, revalidating in background", uri)
+			r.revalidate(uri, stored)
+			return stored.response(now), CacheStale, nil
+		}
+	}
+
+	conditional := http.Header{}
+	if found {
+		stored.conditions(conditional)
+	}
+
+	response, err := fetch(withRequestHeaders(ctx, conditional))
+	if found && (err != nil || response.StatusCode >= http.StatusInternalServerError) &&
+		stored.within("stale-if-error", cache.now(), cache.Shared) {
+
+		Logger.Debug("Cache stale for %s, server failed", uri)
+		if response != nil {
+			_, _ = io.Copy(ioutil.Discard, response.Body)
+			_ = response.Body.Close()
+		}
+		return stored.response(cache.now()), CacheStale, nil
+	}
+	if err != nil {
+		return nil, CacheMiss, err
+	}
+
+	if found && response.StatusCode == http.StatusNotModified {
+		_, _ = io.Copy(ioutil.Discard, response.Body)
+		_ = response.Body.Close()
+
+		Logger.Debug("Cache revalidated for %s", uri)
+		refreshed := cache.refresh(stored, response, now)
+		return refreshed.response(cache.now()), CacheRevalidated, nil
+	}
+
+	response, err = cache.store(uri, header, response, now)
+	return response, CacheMiss, err
+}
+
+// revalidate refreshes stored in background, using a copy of r so it can keep being used
+func (r *Requist) revalidate(uri string, stored *CachedResponse) {
+
+	cache := r.cache
+	if !cache.begin(uri) {
+		return
+	}
+
+	clone := r.snapshot()
+	header := *clone.header
+
+	go func() {
+		defer cache.end(uri)
+
+		conditional := http.Header{}
+		stored.conditions(conditional)
+
+		requested := cache.now()
+		response, err := clone.roundTrip(withRequestHeaders(clone.ctx, conditional), uri, nil)
+		if err != nil {
+			Logger.Warn("Unable to revalidate %s: %s", uri, err)
+			return
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode == http.StatusNotModified {
+			cache.refresh(stored, response, requested)
+			return
+		}
+		if response.StatusCode >= http.StatusInternalServerError {
+			return
+		}
+		if response, err = cache.store(uri, header, response, requested); err == nil {
+			_ = response.Body.Close()
+		}
+	}()
+}
@@ -0,0 +1,414 @@
+package requist
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// MockCacheServer answers with the given Cache-Control and ETag, 304 when If-None-Match matches,
+// and 500 once failing is set
+func MockCacheServer(control, etag string, requests, failing *int32) *httptest.Server {
+
+	return httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			count := atomic.AddInt32(requests, 1)
+			if atomic.LoadInt32(failing) == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			// Without Date, ages only depend on the cache clock
+			w.Header()["Date"] = nil
+			w.Header().Set("Cache-Control", control)
+			w.Header().Set("Vary", "Accept")
+			if etag != "" {
+				w.Header().Set("ETag", etag)
+				if r.Header.Get("If-None-Match") == etag {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+			}
+			w.Header().Set("Content-Type", JSONContentType)
+			_, _ = w.Write([]byte(`{"result": "` + string('0'+count) + `"}`))
+		}),
+	)
+}
+
+// mockCacheClock returns a clock that can be moved forward
+func mockCacheClock(cache *Cache) *int64 {
+
+	offset := new(int64)
+	cache.clock = func() time.Time {
+		return time.Now().Add(time.Duration(atomic.LoadInt64(offset)))
+	}
+	return offset
+}
+
+func TestRequist_SetClientCache(t *testing.T) {
+
+	t.Run("serve fresh responses from cache", func(t *testing.T) {
+
+		var requests, failing int32
+
+		// We create a Mock Server
+		server := MockCacheServer("max-age=60", "", &requests, &failing)
+		defer server.Close()
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.SetClientCache(NewCache(NewMemoryCache(10)))
+		emptyClient.Accept(JSONContentType)
+
+		var statuses []CacheStatus
+		for i := 0; i < 3; i++ {
+			success := &GenericResponse{}
+			_, err := emptyClient.Get("/cached", success, nil)
+			assert.Nil(t, err)
+			assert.Equal(t, "1", success.Result)
+			statuses = append(statuses, emptyClient.CacheStatus())
+		}
+
+		// our data is correct?
+		assert.Equal(t, []CacheStatus{CacheMiss, CacheHit, CacheHit}, statuses)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("revalidate with ETag", func(t *testing.T) {
+
+		var requests, failing int32
+
+		// We create a Mock Server
+		server := MockCacheServer("no-cache", `"v1"`, &requests, &failing)
+		defer server.Close()
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.SetClientCache(NewCache(NewMemoryCache(10)))
+		emptyClient.Accept(JSONContentType)
+
+		_, err := emptyClient.Get("/cached", nil, nil)
+		assert.Nil(t, err)
+
+		success := &GenericResponse{}
+		_, err = emptyClient.Get("/cached", success, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, CacheRevalidated, emptyClient.CacheStatus())
+		assert.Equal(t, http.StatusOK, emptyClient.StatusCode())
+		assert.Equal(t, "1", success.Result)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("revalidate with Last-Modified", func(t *testing.T) {
+
+		modified := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+		var requests int32
+
+		// We create a Mock Server
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			w.Header().Set("Cache-Control", "max-age=0")
+			w.Header().Set("Last-Modified", modified)
+			if r.Header.Get("If-Modified-Since") == modified {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			_, _ = w.Write([]byte(`content`))
+		}))
+		defer server.Close()
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.SetClientCache(NewCache(NewMemoryCache(10)))
+
+		_, err := emptyClient.Get("/cached", nil, nil)
+		assert.Nil(t, err)
+		_, err = emptyClient.Get("/cached", nil, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, CacheRevalidated, emptyClient.CacheStatus())
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("never store no-store responses", func(t *testing.T) {
+
+		var requests, failing int32
+
+		// We create a Mock Server
+		server := MockCacheServer("no-store", `"v1"`, &requests, &failing)
+		defer server.Close()
+
+		// We create our requist Client
+		store := NewMemoryCache(10)
+		emptyClient := New(server.URL)
+		emptyClient.SetClientCache(NewCache(store))
+
+		for i := 0; i < 2; i++ {
+			_, err := emptyClient.Get("/cached", nil, nil)
+			assert.Nil(t, err)
+			assert.Equal(t, CacheMiss, emptyClient.CacheStatus())
+		}
+
+		// our data is correct?
+		assert.Equal(t, 0, store.Len())
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("tell variants apart with Vary", func(t *testing.T) {
+
+		var requests, failing int32
+
+		// We create a Mock Server
+		server := MockCacheServer("max-age=60", "", &requests, &failing)
+		defer server.Close()
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.SetClientCache(NewCache(NewMemoryCache(10)))
+		emptyClient.Accept(JSONContentType)
+
+		_, err := emptyClient.Get("/cached", nil, nil)
+		assert.Nil(t, err)
+
+		emptyClient.Accept(TextContentType)
+		_, err = emptyClient.Get("/cached", nil, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, CacheMiss, emptyClient.CacheStatus())
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("skip private responses on shared caches", func(t *testing.T) {
+
+		var requests, failing int32
+
+		// We create a Mock Server
+		server := MockCacheServer("private, max-age=60", "", &requests, &failing)
+		defer server.Close()
+
+		// We create our requist Client
+		cache := NewCache(NewMemoryCache(10))
+		cache.Shared = true
+		emptyClient := New(server.URL)
+		emptyClient.SetClientCache(cache)
+
+		for i := 0; i < 2; i++ {
+			_, err := emptyClient.Get("/cached", nil, nil)
+			assert.Nil(t, err)
+		}
+
+		// our data is correct?
+		assert.Equal(t, CacheMiss, emptyClient.CacheStatus())
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("serve stale on errors with stale-if-error", func(t *testing.T) {
+
+		var requests, failing int32
+
+		// We create a Mock Server
+		server := MockCacheServer("max-age=60, stale-if-error=600", "", &requests, &failing)
+		defer server.Close()
+
+		// We create our requist Client
+		cache := NewCache(NewMemoryCache(10))
+		offset := mockCacheClock(cache)
+		emptyClient := New(server.URL)
+		emptyClient.SetClientCache(cache)
+		emptyClient.Accept(JSONContentType)
+
+		_, err := emptyClient.Get("/cached", nil, nil)
+		assert.Nil(t, err)
+
+		atomic.StoreInt64(offset, int64(5*time.Minute))
+		atomic.StoreInt32(&failing, 1)
+
+		success := &GenericResponse{}
+		_, err = emptyClient.Get("/cached", success, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, CacheStale, emptyClient.CacheStatus())
+		assert.Equal(t, http.StatusOK, emptyClient.StatusCode())
+		assert.Equal(t, "1", success.Result)
+
+		// past the window errors go through
+		atomic.StoreInt64(offset, int64(time.Hour))
+		_, err = emptyClient.Get("/cached", nil, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusInternalServerError, emptyClient.StatusCode())
+	})
+
+	t.Run("serve stale while revalidating in background", func(t *testing.T) {
+
+		var requests, failing int32
+
+		// We create a Mock Server
+		server := MockCacheServer("max-age=60, stale-while-revalidate=600", "", &requests, &failing)
+		defer server.Close()
+
+		// We create our requist Client
+		cache := NewCache(NewMemoryCache(10))
+		offset := mockCacheClock(cache)
+		emptyClient := New(server.URL)
+		emptyClient.SetClientCache(cache)
+		emptyClient.Accept(JSONContentType)
+
+		_, err := emptyClient.Get("/cached", nil, nil)
+		assert.Nil(t, err)
+
+		atomic.StoreInt64(offset, int64(5*time.Minute))
+
+		success := &GenericResponse{}
+		_, err = emptyClient.Get("/cached", success, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, CacheStale, emptyClient.CacheStatus())
+		assert.Equal(t, "1", success.Result)
+
+		// once revalidated, the new response is fresh
+		assert.Eventually(t, func() bool {
+			stored, ok := cache.lookup(server.URL+"/cached", http.Header{"Accept": {JSONContentType}})
+			return ok && string(stored.Body) == `{"result": "2"}`
+		}, time.Second, 10*time.Millisecond)
+
+		_, err = emptyClient.Get("/cached", success, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, CacheHit, emptyClient.CacheStatus())
+		assert.Equal(t, "2", success.Result)
+	})
+
+	t.Run("invalidate on unsafe methods", func(t *testing.T) {
+
+		var requests, failing int32
+
+		// We create a Mock Server
+		server := MockCacheServer("max-age=60", "", &requests, &failing)
+		defer server.Close()
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.SetClientCache(NewCache(NewMemoryCache(10)))
+
+		_, err := emptyClient.Get("/cached", nil, nil)
+		assert.Nil(t, err)
+		_, err = emptyClient.Delete("/cached", nil, nil)
+		assert.Nil(t, err)
+		_, err = emptyClient.Get("/cached", nil, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, CacheMiss, emptyClient.CacheStatus())
+		assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+	})
+}
+
+func TestCachedResponse_lifetime(t *testing.T) {
+
+	now := time.Now().UTC().Truncate(time.Second)
+	date := now.Format(http.TimeFormat)
+
+	tests := []struct {
+		name     string
+		header   http.Header
+		shared   bool
+		expected time.Duration
+	}{
+		{"max-age", http.Header{"Cache-Control": {"max-age=30"}}, false, 30 * time.Second},
+		{"s-maxage on private", http.Header{"Cache-Control": {"max-age=30, s-maxage=90"}}, false, 30 * time.Second},
+		{"s-maxage on shared", http.Header{"Cache-Control": {"max-age=30, s-maxage=90"}}, true, 90 * time.Second},
+		{"expires", http.Header{"Date": {date}, "Expires": {now.Add(time.Hour).Format(http.TimeFormat)}}, false, time.Hour},
+		{"invalid expires", http.Header{"Expires": {"0"}}, false, 0},
+		{"heuristic", http.Header{"Date": {date}, "Last-Modified": {now.Add(-10 * time.Hour).Format(http.TimeFormat)}}, false, time.Hour},
+		{"nothing", http.Header{}, false, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stored := &CachedResponse{StatusCode: http.StatusOK, Header: test.header, ResponseTime: now}
+
+			// our data is correct?
+			assert.Equal(t, test.expected, stored.lifetime(test.shared))
+		})
+	}
+}
+
+func TestCachedResponse_age(t *testing.T) {
+
+	now := time.Now().UTC().Truncate(time.Second)
+	stored := &CachedResponse{
+		Header:       http.Header{"Date": {now.Add(-10 * time.Second).Format(http.TimeFormat)}, "Age": {"30"}},
+		RequestTime:  now.Add(-2 * time.Second),
+		ResponseTime: now,
+	}
+
+	// our data is correct? Age plus response delay beats the apparent age
+	assert.Equal(t, 32*time.Second, stored.age(now))
+	assert.Equal(t, 92*time.Second, stored.age(now.Add(time.Minute)))
+}
+
+func TestMemoryCache(t *testing.T) {
+
+	store := NewMemoryCache(2)
+	store.Set("a", &CachedResponse{Key: "a"})
+	store.Set("b", &CachedResponse{Key: "b"})
+
+	// touching a makes b the least recently used
+	_, ok := store.Get("a")
+	assert.True(t, ok)
+	store.Set("c", &CachedResponse{Key: "c"})
+
+	// our data is correct?
+	_, ok = store.Get("b")
+	assert.False(t, ok)
+	_, ok = store.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, store.Len())
+
+	store.Delete("a")
+	_, ok = store.Get("a")
+	assert.False(t, ok)
+}
+
+func TestDiskCache(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "requist-cache")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := NewDiskCache(dir)
+	assert.Nil(t, err)
+
+	stored := &CachedResponse{
+		Key:          "GET http://localhost/",
+		StatusCode:   http.StatusOK,
+		Header:       http.Header{"Etag": {`"v1"`}},
+		Body:         []byte("content"),
+		ResponseTime: time.Now().UTC().Truncate(time.Second),
+	}
+	store.Set(stored.Key, stored)
+
+	// a new instance finds what the previous one stored
+	reopened, err := NewDiskCache(dir)
+	assert.Nil(t, err)
+	loaded, ok := reopened.Get(stored.Key)
+
+	// our data is correct?
+	assert.True(t, ok)
+	assert.Equal(t, stored.Body, loaded.Body)
+	assert.Equal(t, `"v1"`, loaded.Header.Get("ETag"))
+	assert.True(t, stored.ResponseTime.Equal(loaded.ResponseTime))
+
+	reopened.Delete(stored.Key)
+	_, ok = store.Get(stored.Key)
+	assert.False(t, ok)
+}
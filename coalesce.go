@@ -0,0 +1,240 @@
+package requist
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//=== Coalescing of identical concurrent GET requests
+
+// defaultCoalesceHeaders are the request headers that make two GET requests different, Cookie
+// holds the cookies of the client CookieJar too
+var defaultCoalesceHeaders = []string{"Accept", "Accept-Encoding", "Accept-Language", "Authorization", "Cookie"}
+
+// sharedResponse is a fully read response handed to every caller of a coalesced request
+type sharedResponse struct {
+	response  *http.Response
+	body      []byte
+	redirects []string
+}
+
+// copy returns a response with its own header and body, so each caller can decode it
+func (s *sharedResponse) copy() *http.Response {
+
+	copied := *s.response
+	copied.Header = s.response.Header.Clone()
+	copied.Body = ioutil.NopCloser(bytes.NewReader(s.body))
+	copied.ContentLength = int64(len(s.body))
+	return &copied
+}
+
+// coalescedCall is a request in flight, waited by its followers
+type coalescedCall struct {
+	done      chan struct{}
+	cancel    context.CancelFunc
+	shared    *sharedResponse
+	err       error
+	followers int
+	waiters   int
+}
+
+// Coalescer makes concurrent identical GET requests share a single upstream call.
+// Every Requist sharing a Coalescer must send the same credentials for the same headers, cookies of
+// their CookieJar included. Requests are never shared while a Signer is set, signatures are added
+// when sending and may carry credentials not seen in the headers.
+// The shared request isn't bound to the context nor Timeout of any caller, only SetClientTimeout
+// bounds it, and callers giving up stop waiting for it.
+// It is safe for concurrent use and can be shared by many Requist
+type Coalescer struct {
+	// Headers are the request headers that must match for requests to be shared
+	Headers []string
+
+	mutex sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+// NewCoalescer returns a Coalescer telling requests apart by URL and Accept, Accept-Encoding,
+// Accept-Language, Authorization and Cookie headers
+func NewCoalescer() *Coalescer {
+
+	headers := make([]string, len(defaultCoalesceHeaders))
+	copy(headers, defaultCoalesceHeaders)
+
+	return &Coalescer{
+		Headers: headers,
+		calls:   map[string]*coalescedCall{},
+	}
+}
+
+// InFlight returns how many distinct requests are being sent
+func (c *Coalescer) InFlight() int {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return len(c.calls)
+}
+
+// key identifies a request by method, uri and relevant headers
+func (c *Coalescer) key(method, uri string, header http.Header) string {
+
+	headers := make([]string, len(c.Headers))
+	copy(headers, c.Headers)
+	sort.Strings(headers)
+
+	var key strings.Builder
+	key.WriteString(method + " " + uri)
+	for _, name := range headers {
+		key.WriteString("\n" + http.CanonicalHeaderKey(name) + ": " + strings.Join(header.Values(name), ", "))
+	}
+	return key.String()
+}
+
+// do runs fetch once for every concurrent call with the same key, returning its response to all of them.
+// fetch runs on detached, not on any caller context, so callers giving up don't fail the others, and it's
+// canceled only when every caller gave up. Each caller waits until its ctx is done
+func (c *Coalescer) do(ctx, detached context.Context, key string, fetch func(ctx context.Context) (*sharedResponse, error)) (*sharedResponse, error) {
+
+	c.mutex.Lock()
+	if c.calls == nil {
+		c.calls = map[string]*coalescedCall{}
+	}
+	call, ok := c.calls[key]
+	if ok {
+		call.followers++
+	} else {
+		fetchCtx, cancel := context.WithCancel(detached)
+		call = &coalescedCall{done: make(chan struct{}), cancel: cancel}
+		c.calls[key] = call
+		go c.run(fetchCtx, key, call, fetch)
+	}
+	call.waiters++
+	c.mutex.Unlock()
+
+	select {
+	case <-call.done:
+		return call.shared, call.err
+	case <-ctx.Done():
+	}
+
+	c.mutex.Lock()
+	call.waiters--
+	if call.waiters == 0 {
+		call.cancel()
+		if c.calls[key] == call {
+			delete(c.calls, key)
+		}
+	}
+	c.mutex.Unlock()
+	return nil, ctx.Err()
+}
+
+// run sends call through fetch with ctx and hands its response to the callers waiting for it
+func (c *Coalescer) run(ctx context.Context, key string, call *coalescedCall, fetch func(ctx context.Context) (*sharedResponse, error)) {
+
+	call.shared, call.err = fetch(ctx)
+
+	c.mutex.Lock()
+	if c.calls[key] == call {
+		delete(c.calls, key)
+	}
+	followers := call.followers
+	c.mutex.Unlock()
+	call.cancel()
+	close(call.done)
+
+	if followers > 0 {
+		Logger.Debug("Coalesced %d Requests into one", followers+1)
+	}
+}
+
+//=== Requist integration
+
+// SetClientCoalescer take coalescer param and share identical concurrent GET requests through it, nil disables it.
+// Signed requests are never shared
+func (r *Requist) SetClientCoalescer(coalescer *Coalescer) {
+
+	Logger.Debug("Setting Client Coalescer")
+
+	r.coalescer = coalescer
+}
+
+// coalesce sends uri through send, unless an identical request is in flight, then its response is shared.
+// It's sent with a snapshot of r, as it may outlive this request
+func (r *Requist) coalesce(ctx context.Context, uri string, send func(client *Requist, ctx context.Context) (*http.Response, error)) (*http.Response, error) {
+
+	chain, _ := ctx.Value(redirectChainKey{}).(*redirectChain)
+
+	// Headers added for this request only (ie: cache validators) tell requests apart too
+	key := r.coalescer.key(r.method, uri, r.coalesceHeader(uri))
+	extra := requestHeaders(ctx)
+	names := make([]string, 0, len(extra))
+	for name := range extra {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		key += "\n" + name + ": " + strings.Join(extra[name], ", ")
+	}
+
+	// The shared request has its own redirect chain, but carries the headers of this one
+	client := r.snapshot()
+	detached := withRedirectChain(context.Background(), &redirectChain{})
+	if extra != nil {
+		detached = withRequestHeaders(detached, extra)
+	}
+
+	result, err := r.coalescer.do(ctx, detached, key, func(fetchCtx context.Context) (*sharedResponse, error) {
+
+		response, err := send(client, fetchCtx)
+		if err != nil {
+			return nil, err
+		}
+		defer response.Body.Close()
+
+		body, err := ioutil.ReadAll(response.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		result := &sharedResponse{response: response, body: body}
+		if chain, ok := fetchCtx.Value(redirectChainKey{}).(*redirectChain); ok {
+			result.redirects = chain.list()
+		}
+		return result, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Callers didn't follow any redirect themselves, they take the chain of the shared request
+	if chain != nil {
+		for _, redirect := range result.redirects {
+			chain.add(redirect)
+		}
+	}
+	return result.copy(), nil
+}
+
+// coalesceHeader returns the client headers sent to uri, with the cookies the CookieJar will add
+func (r *Requist) coalesceHeader(uri string) http.Header {
+
+	header := r.header.Clone()
+	if r.client.Jar == nil {
+		return header
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return header
+	}
+	for _, cookie := range r.client.Jar.Cookies(u) {
+		header.Add("Cookie", cookie.String())
+	}
+	return header
+}
@@ -0,0 +1,299 @@
+package requist
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// followers returns how many callers are waiting on calls in flight
+func (c *Coalescer) followers() int {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	total := 0
+	for _, call := range c.calls {
+		total += call.followers
+	}
+	return total
+}
+
+func TestRequist_SetClientCoalescer(t *testing.T) {
+
+	var requests int32
+	unblock := make(chan struct{})
+
+	// We create a Mock Server that blocks until unblock is closed
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-unblock
+		w.Header().Set("Content-Type", JSONContentType)
+		_, _ = w.Write([]byte(`{"result": "` + r.Header.Get("Authorization") + `"}`))
+	}))
+	defer server.Close()
+
+	t.Run("share identical concurrent requests", func(t *testing.T) {
+
+		atomic.StoreInt32(&requests, 0)
+		unblock = make(chan struct{})
+		coalescer := NewCoalescer()
+
+		results := make([]*GenericResponse, 4)
+		var group sync.WaitGroup
+		for i := range results {
+			results[i] = &GenericResponse{}
+			group.Add(1)
+			go func(success *GenericResponse) {
+				defer group.Done()
+
+				// We create our requist Client
+				emptyClient := New(server.URL)
+				emptyClient.SetClientCoalescer(coalescer)
+				emptyClient.Accept(JSONContentType)
+				emptyClient.SetHeader("Authorization", "one")
+
+				_, err := emptyClient.Get("/shared", success, nil)
+				assert.Nil(t, err)
+				assert.Equal(t, http.StatusOK, emptyClient.StatusCode())
+			}(results[i])
+		}
+
+		assert.Eventually(t, func() bool { return coalescer.followers() == 3 }, time.Second, 5*time.Millisecond)
+		close(unblock)
+		group.Wait()
+
+		// our data is correct?
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+		for _, success := range results {
+			assert.Equal(t, "one", success.Result)
+		}
+		assert.Equal(t, 0, coalescer.InFlight())
+	})
+
+	t.Run("don't share requests with different headers", func(t *testing.T) {
+
+		atomic.StoreInt32(&requests, 0)
+		unblock = make(chan struct{})
+		coalescer := NewCoalescer()
+
+		results := map[string]*GenericResponse{"one": {}, "two": {}}
+		var group sync.WaitGroup
+		for auth, success := range results {
+			group.Add(1)
+			go func(auth string, success *GenericResponse) {
+				defer group.Done()
+
+				// We create our requist Client
+				emptyClient := New(server.URL)
+				emptyClient.SetClientCoalescer(coalescer)
+				emptyClient.Accept(JSONContentType)
+				emptyClient.SetHeader("Authorization", auth)
+
+				_, err := emptyClient.Get("/shared", success, nil)
+				assert.Nil(t, err)
+			}(auth, success)
+		}
+
+		assert.Eventually(t, func() bool { return atomic.LoadInt32(&requests) == 2 }, time.Second, 5*time.Millisecond)
+		close(unblock)
+		group.Wait()
+
+		// our data is correct?
+		for auth, success := range results {
+			assert.Equal(t, auth, success.Result)
+		}
+	})
+
+	t.Run("don't share requests with different jar cookies", func(t *testing.T) {
+
+		atomic.StoreInt32(&requests, 0)
+		unblock = make(chan struct{})
+		coalescer := NewCoalescer()
+		target, _ := url.Parse(server.URL)
+
+		var group sync.WaitGroup
+		for _, session := range []string{"one", "two"} {
+			jar, err := NewCookieJar()
+			assert.Nil(t, err)
+			jar.SetCookies(target, []*http.Cookie{{Name: "session", Value: session}})
+
+			group.Add(1)
+			go func(jar http.CookieJar) {
+				defer group.Done()
+
+				// We create our requist Client
+				emptyClient := New(server.URL)
+				emptyClient.SetClientCoalescer(coalescer)
+				emptyClient.SetClientCookieJar(jar)
+
+				_, err := emptyClient.Get("/shared", nil, nil)
+				assert.Nil(t, err)
+			}(jar)
+		}
+
+		assert.Eventually(t, func() bool { return atomic.LoadInt32(&requests) == 2 }, time.Second, 5*time.Millisecond)
+		close(unblock)
+		group.Wait()
+	})
+
+	t.Run("don't share signed requests", func(t *testing.T) {
+
+		atomic.StoreInt32(&requests, 0)
+		unblock = make(chan struct{})
+		coalescer := NewCoalescer()
+
+		var group sync.WaitGroup
+		for _, key := range []string{"one", "two"} {
+			group.Add(1)
+			go func(key string) {
+				defer group.Done()
+
+				// We create our requist Client
+				emptyClient := New(server.URL)
+				emptyClient.SetClientCoalescer(coalescer)
+				emptyClient.SetSigner(NewHMACSigner([]byte(key)))
+
+				_, err := emptyClient.Get("/shared", nil, nil)
+				assert.Nil(t, err)
+			}(key)
+		}
+
+		assert.Eventually(t, func() bool { return atomic.LoadInt32(&requests) == 2 }, time.Second, 5*time.Millisecond)
+		close(unblock)
+		group.Wait()
+
+		// our data is correct?
+		assert.Equal(t, 0, coalescer.InFlight())
+	})
+
+	t.Run("stop waiting when a follower gives up", func(t *testing.T) {
+
+		atomic.StoreInt32(&requests, 0)
+		unblock = make(chan struct{})
+		coalescer := NewCoalescer()
+
+		leader := &GenericResponse{}
+		done := make(chan error)
+		go func() {
+			// We create our requist Client
+			emptyClient := New(server.URL)
+			emptyClient.SetClientCoalescer(coalescer)
+			emptyClient.Accept(JSONContentType)
+			emptyClient.SetHeader("Authorization", "one")
+
+			_, err := emptyClient.Get("/shared", leader, nil)
+			done <- err
+		}()
+		assert.Eventually(t, func() bool { return atomic.LoadInt32(&requests) == 1 }, time.Second, 5*time.Millisecond)
+
+		// We create our requist Client
+		follower := New(server.URL)
+		follower.SetClientCoalescer(coalescer)
+		follower.Accept(JSONContentType)
+		follower.SetHeader("Authorization", "one")
+
+		_, err := follower.Timeout(20*time.Millisecond).Get("/shared", &GenericResponse{}, nil)
+
+		// our data is correct?
+		assert.True(t, errors.Is(err, ErrTimeout))
+		assert.Equal(t, 1, coalescer.InFlight())
+
+		close(unblock)
+		assert.Nil(t, <-done)
+		assert.Equal(t, "one", leader.Result)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("keep sharing when the leader gives up", func(t *testing.T) {
+
+		atomic.StoreInt32(&requests, 0)
+		unblock = make(chan struct{})
+		coalescer := NewCoalescer()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		done := make(chan error)
+		go func() {
+			// We create our requist Client
+			emptyClient := New(server.URL)
+			emptyClient.SetClientCoalescer(coalescer)
+			emptyClient.Accept(JSONContentType)
+			emptyClient.SetHeader("Authorization", "one")
+
+			_, err := emptyClient.WithContext(ctx).Get("/shared", &GenericResponse{}, nil)
+			done <- err
+		}()
+		assert.Eventually(t, func() bool { return atomic.LoadInt32(&requests) == 1 }, time.Second, 5*time.Millisecond)
+
+		follower := &GenericResponse{}
+		go func() {
+			// We create our requist Client
+			emptyClient := New(server.URL)
+			emptyClient.SetClientCoalescer(coalescer)
+			emptyClient.Accept(JSONContentType)
+			emptyClient.SetHeader("Authorization", "one")
+
+			_, err := emptyClient.Get("/shared", follower, nil)
+			done <- err
+		}()
+		assert.Eventually(t, func() bool { return coalescer.followers() == 1 }, time.Second, 5*time.Millisecond)
+
+		cancel()
+		err := <-done
+
+		// our data is correct?
+		assert.True(t, errors.Is(err, ErrCanceled))
+
+		close(unblock)
+		assert.Nil(t, <-done)
+		assert.Equal(t, "one", follower.Result)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+		assert.Equal(t, 0, coalescer.InFlight())
+	})
+
+	t.Run("let the client be set while the shared request finishes", func(t *testing.T) {
+
+		atomic.StoreInt32(&requests, 0)
+		unblock = make(chan struct{})
+		defer close(unblock)
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.SetClientCoalescer(NewCoalescer())
+
+		_, err := emptyClient.Timeout(5*time.Millisecond).Get("/shared", nil, nil)
+		assert.True(t, errors.Is(err, ErrTimeout))
+
+		// Run with -race, the shared request must not read the client being set
+		emptyClient.SetClientCircuitBreaker(NewCircuitBreaker())
+		emptyClient.SetClientThrottler(NewThrottler())
+		emptyClient.SetClientTimeout(time.Second)
+
+		// our data is correct?
+		assert.Eventually(t, func() bool { return emptyClient.coalescer.InFlight() == 0 }, time.Second, 5*time.Millisecond)
+	})
+}
+
+func TestCoalescer_key(t *testing.T) {
+
+	coalescer := NewCoalescer()
+
+	one := http.Header{"Accept": {JSONContentType}, "X-Request-Id": {"1"}}
+	two := http.Header{"Accept": {JSONContentType}, "X-Request-Id": {"2"}}
+	text := http.Header{"Accept": {TextContentType}}
+
+	// our data is correct?
+	assert.Equal(t, coalescer.key(http.MethodGet, "/a", one), coalescer.key(http.MethodGet, "/a", two))
+	assert.NotEqual(t, coalescer.key(http.MethodGet, "/a", one), coalescer.key(http.MethodGet, "/b", one))
+	assert.NotEqual(t, coalescer.key(http.MethodGet, "/a", one), coalescer.key(http.MethodGet, "/a", text))
+
+	coalescer.Headers = append(coalescer.Headers, "X-Request-Id")
+	assert.NotEqual(t, coalescer.key(http.MethodGet, "/a", one), coalescer.key(http.MethodGet, "/a", two))
+}
@@ -16,4 +16,9 @@ const (
 
 	// Timeout of http.Client default, 4 seconds
 	defaultTimeout = 4 * time.Second
+
+	// unixHTTPScheme is the prefix of bases carrying a percent-encoded socket path as host
+	unixHTTPScheme string = "http+unix://"
+	// unixBaseURL is the base used at HTTP layer while talking through a unix socket
+	unixBaseURL string = "http://localhost"
 )
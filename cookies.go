@@ -0,0 +1,227 @@
+package requist
+
+import (
+	"encoding/json"
+	"golang.org/x/net/publicsuffix"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+//=== Cookies handling
+
+// NewCookieJar returns an in-memory http.CookieJar using public suffix aware domain matching
+func NewCookieJar() (http.CookieJar, error) {
+
+	return cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+}
+
+// SetClientCookieJar take jar param and set client HTTP CookieJar, nil disables cookies
+func (r *Requist) SetClientCookieJar(jar http.CookieJar) {
+
+	Logger.Debug("Setting Client CookieJar (%T)", jar)
+
+	r.client.Jar = jar
+}
+
+//=== PersistentJar implementation of http.CookieJar interface
+
+// persistentCookie is the on-disk representation of a cookie
+type persistentCookie struct {
+	URL      string        `json:"url"`
+	Name     string        `json:"name"`
+	Value    string        `json:"value"`
+	Path     string        `json:"path,omitempty"`
+	Domain   string        `json:"domain,omitempty"`
+	Expires  time.Time     `json:"expires,omitempty"`
+	Secure   bool          `json:"secure,omitempty"`
+	HTTPOnly bool          `json:"http_only,omitempty"`
+	SameSite http.SameSite `json:"same_site,omitempty"`
+}
+
+// key identifies a cookie by origin, domain, path and name
+func (c persistentCookie) key() string {
+
+	origin := c.URL
+	if u, err := url.Parse(c.URL); err == nil {
+		origin = u.Scheme + "://" + u.Host
+	}
+	return c.Domain + ";" + c.Path + ";" + c.Name + ";" + origin
+}
+
+// defaultPath returns the path of cookies set without one from a request to path (RFC 6265 section 5.1.4)
+func defaultPath(path string) string {
+
+	if !strings.HasPrefix(path, "/") {
+		return "/"
+	}
+	i := strings.LastIndex(path, "/")
+	if i == 0 {
+		return "/"
+	}
+	return path[:i]
+}
+
+// expired returns true if the cookie must not be persisted anymore
+func (c persistentCookie) expired(now time.Time) bool {
+
+	return !c.Expires.IsZero() && !c.Expires.After(now)
+}
+
+// PersistentJar is an http.CookieJar that keeps cookies in a JSON file across runs
+type PersistentJar struct {
+	// AutoSave writes the file every time the server sets cookies
+	AutoSave bool
+	// KeepSessionCookies writes cookies without Expires nor Max-Age too, as login sessions usually are
+	KeepSessionCookies bool
+
+	mutex    sync.Mutex
+	filename string
+	jar      http.CookieJar
+	entries  map[string]persistentCookie
+}
+
+// NewPersistentJar returns a PersistentJar backed by filename, loading it if exists. Session cookies are kept
+func NewPersistentJar(filename string) (*PersistentJar, error) {
+
+	jar, err := NewCookieJar()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &PersistentJar{
+		AutoSave:           true,
+		KeepSessionCookies: true,
+		filename:           filename,
+		jar:                jar,
+		entries:            map[string]persistentCookie{},
+	}
+
+	if err = p.Load(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// SetCookies implements http.CookieJar interface and tracks cookies to be persisted
+func (p *PersistentJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+
+	p.jar.SetCookies(u, cookies)
+
+	p.mutex.Lock()
+	now := time.Now()
+	requested := (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}).String()
+	for _, cookie := range cookies {
+		entry := persistentCookie{
+			URL:      requested,
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Path:     cookie.Path,
+			Domain:   cookie.Domain,
+			Expires:  cookie.Expires,
+			Secure:   cookie.Secure,
+			HTTPOnly: cookie.HttpOnly,
+			SameSite: cookie.SameSite,
+		}
+		if cookie.MaxAge > 0 {
+			entry.Expires = now.Add(time.Duration(cookie.MaxAge) * time.Second)
+		}
+
+		// Cookies without path only match below the request one, even when loaded from another URL
+		if !strings.HasPrefix(entry.Path, "/") {
+			entry.Path = defaultPath(u.Path)
+		}
+
+		// Deletions are never written to disk, session cookies only if they are kept
+		if cookie.MaxAge < 0 || entry.expired(now) || (entry.Expires.IsZero() && !p.KeepSessionCookies) {
+			delete(p.entries, entry.key())
+			continue
+		}
+		p.entries[entry.key()] = entry
+	}
+	p.mutex.Unlock()
+
+	if p.AutoSave {
+		if err := p.Save(); err != nil {
+			Logger.Error("Unable to save cookies into %s: %s", p.filename, err)
+		}
+	}
+}
+
+// Cookies implements http.CookieJar interface
+func (p *PersistentJar) Cookies(u *url.URL) []*http.Cookie {
+
+	return p.jar.Cookies(u)
+}
+
+// Load reads cookies from the jar file, a missing file is not an error
+func (p *PersistentJar) Load() error {
+
+	content, err := ioutil.ReadFile(p.filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var entries []persistentCookie
+	if err = json.Unmarshal(content, &entries); err != nil {
+		return err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	now := time.Now()
+	for _, entry := range entries {
+		if entry.expired(now) {
+			continue
+		}
+		u, err := url.Parse(entry.URL)
+		if err != nil {
+			return err
+		}
+		p.jar.SetCookies(u, []*http.Cookie{{
+			Name:     entry.Name,
+			Value:    entry.Value,
+			Path:     entry.Path,
+			Domain:   entry.Domain,
+			Expires:  entry.Expires,
+			Secure:   entry.Secure,
+			HttpOnly: entry.HTTPOnly,
+			SameSite: entry.SameSite,
+		}})
+		p.entries[entry.key()] = entry
+	}
+	return nil
+}
+
+// Save writes not expired cookies into the jar file
+func (p *PersistentJar) Save() error {
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	now := time.Now()
+	entries := make([]persistentCookie, 0, len(p.entries))
+	for key, entry := range p.entries {
+		if entry.expired(now) {
+			delete(p.entries, key)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	content, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFile(p.filename, content)
+}
@@ -0,0 +1,208 @@
+package requist
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// MockCookieServer sets a session cookie on /login and requires it on /me
+func MockCookieServer() *httptest.Server {
+
+	return httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/login":
+				http.SetCookie(w, &http.Cookie{Name: "session", Value: "s3cr3t", Path: "/", MaxAge: 3600})
+				w.WriteHeader(http.StatusNoContent)
+			case "/me":
+				if cookie, err := r.Cookie("session"); err != nil || cookie.Value != "s3cr3t" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}
+		}),
+	)
+}
+
+func TestRequist_SetClientCookieJar(t *testing.T) {
+
+	// We create a Mock Server
+	server := MockCookieServer()
+	defer server.Close()
+
+	t.Run("lose session without CookieJar", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+
+		_, err := emptyClient.Get("/login", nil, nil)
+		assert.Nil(t, err)
+
+		_, err = emptyClient.Get("/me", nil, nil)
+		assert.Nil(t, err)
+
+		// our data is correct?
+		assert.EqualValues(t, http.StatusUnauthorized, emptyClient.StatusCode())
+	})
+
+	t.Run("keep session with CookieJar", func(t *testing.T) {
+
+		jar, err := NewCookieJar()
+		assert.Nil(t, err)
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.SetClientCookieJar(jar)
+
+		// was modified out Client?
+		assert.EqualValues(t, jar, emptyClient.client.Jar)
+
+		_, err = emptyClient.Get("/login", nil, nil)
+		assert.Nil(t, err)
+
+		_, err = emptyClient.Get("/me", nil, nil)
+		assert.Nil(t, err)
+
+		// our data is correct?
+		assert.EqualValues(t, http.StatusNoContent, emptyClient.StatusCode())
+	})
+}
+
+func TestPersistentJar(t *testing.T) {
+
+	// We create a Mock Server
+	server := MockCookieServer()
+	defer server.Close()
+
+	// We create a temporary place to store the jar
+	dir, err := ioutil.TempDir("", "requist")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "cookies.json")
+
+	t.Run("save session on login", func(t *testing.T) {
+
+		jar, err := NewPersistentJar(filename)
+		assert.Nil(t, err)
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.SetClientCookieJar(jar)
+
+		_, err = emptyClient.Get("/login", nil, nil)
+		assert.Nil(t, err)
+
+		// our data is correct?
+		assert.FileExists(t, filename)
+	})
+
+	t.Run("restore session from file", func(t *testing.T) {
+
+		jar, err := NewPersistentJar(filename)
+		assert.Nil(t, err)
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.SetClientCookieJar(jar)
+
+		_, err = emptyClient.Get("/me", nil, nil)
+		assert.Nil(t, err)
+
+		// our data is correct?
+		assert.EqualValues(t, http.StatusNoContent, emptyClient.StatusCode())
+	})
+}
+
+func TestPersistentJar_SessionCookies(t *testing.T) {
+
+	// We create a Mock Server setting a session cookie without Path on /api/login
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s3cr3t"})
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if cookie, err := r.Cookie("session"); err != nil || cookie.Value != "s3cr3t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	// We create a temporary place to store the jar
+	dir, err := ioutil.TempDir("", "requist")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	t.Run("keep session cookies across runs", func(t *testing.T) {
+
+		filename := filepath.Join(dir, "session.json")
+
+		jar, err := NewPersistentJar(filename)
+		assert.Nil(t, err)
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.SetClientCookieJar(jar)
+
+		_, err = emptyClient.Get("/api/login", nil, nil)
+		assert.Nil(t, err)
+
+		restored, err := NewPersistentJar(filename)
+		assert.Nil(t, err)
+
+		// We create our requist Client
+		emptyClient = New(server.URL)
+		emptyClient.SetClientCookieJar(restored)
+
+		_, err = emptyClient.Get("/api/me", nil, nil)
+		assert.Nil(t, err)
+
+		// our data is correct?
+		assert.EqualValues(t, http.StatusNoContent, emptyClient.StatusCode())
+
+		// the cookie keeps its default path
+		_, err = emptyClient.Get("/me", nil, nil)
+		assert.Nil(t, err)
+		assert.EqualValues(t, http.StatusUnauthorized, emptyClient.StatusCode())
+	})
+
+	t.Run("drop session cookies when not kept", func(t *testing.T) {
+
+		filename := filepath.Join(dir, "persistent.json")
+
+		jar, err := NewPersistentJar(filename)
+		assert.Nil(t, err)
+		jar.KeepSessionCookies = false
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.SetClientCookieJar(jar)
+
+		_, err = emptyClient.Get("/api/login", nil, nil)
+		assert.Nil(t, err)
+
+		restored, err := NewPersistentJar(filename)
+		assert.Nil(t, err)
+
+		// our data is correct?
+		assert.Empty(t, restored.entries)
+	})
+}
+
+func TestDefaultPath(t *testing.T) {
+
+	// our data is correct?
+	assert.Equal(t, "/", defaultPath(""))
+	assert.Equal(t, "/", defaultPath("/login"))
+	assert.Equal(t, "/api", defaultPath("/api/login"))
+	assert.Equal(t, "/api/v1", defaultPath("/api/v1/"))
+}
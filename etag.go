@@ -0,0 +1,148 @@
+package requist
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+//=== Optimistic concurrency with ETag and preconditions
+
+var (
+	// ErrPreconditionFailed is returned when the server answers 412 to a request sent with preconditions
+	ErrPreconditionFailed = errors.New("precondition failed")
+	// ErrMissingETag is returned by Update when the resource read has no ETag
+	ErrMissingETag = errors.New("response has no ETag")
+)
+
+// preconditionHeaders are the request headers making a request conditional
+var preconditionHeaders = []string{"If-Match", "If-None-Match", "If-Modified-Since", "If-Unmodified-Since", "If-Range"}
+
+// PreconditionFailedError describes a request rejected with 412 Precondition Failed
+type PreconditionFailedError struct {
+	// URI of the request
+	URI string
+	// ETag is the current ETag of the resource, if the server sent it
+	ETag string
+}
+
+// Error implements error interface
+func (e *PreconditionFailedError) Error() string {
+
+	if e.ETag != "" {
+		return fmt.Sprintf("%s for %s, current ETag is %s", ErrPreconditionFailed, e.URI, e.ETag)
+	}
+	return fmt.Sprintf("%s for %s", ErrPreconditionFailed, e.URI)
+}
+
+// Unwrap allows errors.Is(err, ErrPreconditionFailed)
+func (e *PreconditionFailedError) Unwrap() error {
+
+	return ErrPreconditionFailed
+}
+
+// hasPreconditions tells if header makes a request conditional
+func hasPreconditions(header http.Header) bool {
+
+	for _, name := range preconditionHeaders {
+		if header.Get(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+//=== Requist integration
+
+// ETag return the ETag of last response, empty if none
+func (r *Requist) ETag() string {
+
+	return r.etag
+}
+
+// LastModified return the Last-Modified of last response, zero if none
+func (r *Requist) LastModified() time.Time {
+
+	return r.lastModified
+}
+
+// IfMatch sends If-Match with etag on next request only, so it fails with ErrPreconditionFailed
+// if the resource changed
+func (r *Requist) IfMatch(etag string) *Requist {
+
+	return r.SetRequestHeader("If-Match", etag)
+}
+
+// IfNoneMatch sends If-None-Match with etag on next request only, use "*" to create a resource
+// only if it doesn't exist
+func (r *Requist) IfNoneMatch(etag string) *Requist {
+
+	return r.SetRequestHeader("If-None-Match", etag)
+}
+
+// IfUnmodifiedSince sends If-Unmodified-Since with since on next request only
+func (r *Requist) IfUnmodifiedSince(since time.Time) *Requist {
+
+	return r.SetRequestHeader("If-Unmodified-Since", since.UTC().Format(http.TimeFormat))
+}
+
+// Update reads path into resource, calls modify to change it and writes it back as JSON with method (PUT or PATCH)
+// and If-Match, so concurrent changes aren't lost. When someone else changed the resource in the meantime,
+// it starts over, up to attempts times. The write response, if any, is decoded into resource too.
+// The resource is always read as JSON, and it must hold one, otherwise nothing is written.
+// A context or Timeout set for next request covers every read and write
+func (r *Requist) Update(method, path string, resource interface{}, modify func() error, attempts int) (*Requist, error) {
+
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	target := reflect.ValueOf(resource)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return r, fmt.Errorf("resource must be a non nil pointer, got %T", resource)
+	}
+
+	ctx, cancel := r.requestContext()
+	defer cancel()
+
+	for attempt := 1; ; attempt++ {
+
+		// Fields missing in a new read mustn't keep values from the previous one
+		target.Elem().Set(reflect.Zero(target.Elem().Type()))
+
+		// Always ask the server, a stored copy would fail again, and read JSON as it's written back
+		r.WithContext(ctx).SetRequestHeader("Cache-Control", "no-cache")
+		if r.response == nil || r.response.Accept() != JSONContentType {
+			r.scopedResponse = jsonResponse{}
+		}
+		if _, err := r.Get(path, resource, nil); err != nil {
+			return r, err
+		}
+		if r.statuscode < 200 || r.statuscode > 299 {
+			return r, fmt.Errorf("reading %s answered %d", path, r.statuscode)
+		}
+		if !hasBody(http.MethodGet, r.statuscode) {
+			return r, fmt.Errorf("reading %s answered %d without a resource", path, r.statuscode)
+		}
+
+		etag := r.etag
+		if etag == "" {
+			return r, fmt.Errorf("%w: %s", ErrMissingETag, path)
+		}
+
+		if err := modify(); err != nil {
+			return r, err
+		}
+
+		_, err := r.WithContext(ctx).BodyAsJSON(resource).IfMatch(etag).Method(method).Path(path).Request(resource, nil)
+		if err == nil && (r.statuscode < 200 || r.statuscode > 299) {
+			return r, fmt.Errorf("writing %s answered %d", path, r.statuscode)
+		}
+		if !errors.Is(err, ErrPreconditionFailed) || attempt >= attempts {
+			return r, err
+		}
+		Logger.Debug("Resource %s changed meanwhile, updating it again (%d/%d)", path, attempt+1, attempts)
+	}
+}
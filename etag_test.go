@@ -0,0 +1,262 @@
+package requist
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockResource is a versioned resource served by MockResourceServer
+type mockResource struct {
+	mutex   sync.Mutex
+	version int
+	value   string
+}
+
+// change updates the resource as another client would
+func (m *mockResource) change(value string) {
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.version++
+	m.value = value
+}
+
+// MockResourceServer serves resource with an ETag, rejecting writes whose If-Match is outdated
+func MockResourceServer(resource *mockResource) *httptest.Server {
+
+	return httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			resource.mutex.Lock()
+			defer resource.mutex.Unlock()
+
+			etag := fmt.Sprintf(`"v%d"`, resource.version)
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Content-Type", JSONContentType)
+
+			if r.Method == http.MethodPut {
+				if match := r.Header.Get("If-Match"); match != "" && match != etag {
+					w.WriteHeader(http.StatusPreconditionFailed)
+					return
+				}
+				body := &GenericResponse{}
+				_ = json.NewDecoder(r.Body).Decode(body)
+				resource.version++
+				resource.value = body.Result
+				w.Header().Set("ETag", fmt.Sprintf(`"v%d"`, resource.version))
+			}
+			_, _ = w.Write([]byte(`{"result": "` + resource.value + `"}`))
+		}),
+	)
+}
+
+func TestRequist_IfMatch(t *testing.T) {
+
+	resource := &mockResource{value: "first"}
+
+	// We create a Mock Server
+	server := MockResourceServer(resource)
+	defer server.Close()
+
+	// We create our requist Client
+	emptyClient := New(server.URL)
+	emptyClient.Accept(JSONContentType)
+
+	_, err := emptyClient.Get("/resource", nil, nil)
+	assert.Nil(t, err)
+	etag := emptyClient.ETag()
+	assert.Equal(t, `"v0"`, etag)
+
+	resource.change("second")
+
+	_, err = emptyClient.BodyAsJSON(&GenericResponse{Result: "third"}).IfMatch(etag).Put("/resource", nil, nil)
+
+	// our data is correct?
+	var precondition *PreconditionFailedError
+	assert.True(t, errors.Is(err, ErrPreconditionFailed))
+	assert.True(t, errors.As(err, &precondition))
+	assert.Equal(t, `"v1"`, precondition.ETag)
+	assert.Equal(t, http.StatusPreconditionFailed, emptyClient.StatusCode())
+
+	// preconditions only apply once
+	_, err = emptyClient.BodyAsJSON(&GenericResponse{Result: "third"}).Put("/resource", nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, emptyClient.StatusCode())
+}
+
+func TestRequist_IfUnmodifiedSince(t *testing.T) {
+
+	since := time.Date(2020, 8, 20, 10, 30, 0, 0, time.FixedZone("UTC-3", -3*3600))
+	var received string
+
+	// We create a Mock Server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("If-Unmodified-Since")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	// We create our requist Client
+	emptyClient := New(server.URL)
+	_, err := emptyClient.IfUnmodifiedSince(since).Delete("/resource", nil, nil)
+
+	// our data is correct?
+	assert.Nil(t, err)
+	assert.Equal(t, "Thu, 20 Aug 2020 13:30:00 GMT", received)
+}
+
+func TestRequist_Update(t *testing.T) {
+
+	t.Run("retry when resource changed meanwhile", func(t *testing.T) {
+
+		resource := &mockResource{value: "first"}
+
+		// We create a Mock Server
+		server := MockResourceServer(resource)
+		defer server.Close()
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.Accept(JSONContentType)
+
+		calls := 0
+		current := &GenericResponse{}
+		_, err := emptyClient.Update(http.MethodPut, "/resource", current, func() error {
+			calls++
+			if calls == 1 {
+				resource.change("concurrent")
+			}
+			current.Result += "+mine"
+			return nil
+		}, 3)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, 2, calls)
+		assert.Equal(t, "concurrent+mine", current.Result)
+		assert.Equal(t, "concurrent+mine", resource.value)
+		assert.Equal(t, `"v2"`, emptyClient.ETag())
+	})
+
+	t.Run("give up after attempts", func(t *testing.T) {
+
+		resource := &mockResource{value: "first"}
+
+		// We create a Mock Server
+		server := MockResourceServer(resource)
+		defer server.Close()
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.Accept(JSONContentType)
+
+		calls := 0
+		_, err := emptyClient.Update(http.MethodPut, "/resource", &GenericResponse{}, func() error {
+			calls++
+			resource.change("concurrent")
+			return nil
+		}, 2)
+
+		// our data is correct?
+		assert.True(t, errors.Is(err, ErrPreconditionFailed))
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("fail without ETag", func(t *testing.T) {
+
+		// We create a Mock Server
+		server := MockHTTPServer()
+		defer server.Close()
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+
+		_, err := emptyClient.Update(http.MethodPut, "/user", &GenericResponse{}, func() error { return nil }, 1)
+
+		// our data is correct?
+		assert.True(t, errors.Is(err, ErrMissingETag))
+	})
+
+	t.Run("fail when the write is rejected", func(t *testing.T) {
+
+		// We create a Mock Server rejecting writes
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Content-Type", JSONContentType)
+			if r.Method == http.MethodPut {
+				w.WriteHeader(http.StatusConflict)
+			}
+			_, _ = w.Write([]byte(`{"result": "first"}`))
+		}))
+		defer server.Close()
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+
+		_, err := emptyClient.Update(http.MethodPut, "/resource", &GenericResponse{}, func() error { return nil }, 1)
+
+		// our data is correct?
+		assert.NotNil(t, err)
+		assert.Equal(t, http.StatusConflict, emptyClient.StatusCode())
+	})
+
+	t.Run("read JSON without Accept", func(t *testing.T) {
+
+		resource := &mockResource{value: "first"}
+
+		// We create a Mock Server
+		server := MockResourceServer(resource)
+		defer server.Close()
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+
+		current := &GenericResponse{Result: "stale"}
+		_, err := emptyClient.Update(http.MethodPut, "/resource", current, func() error {
+			current.Result += "+mine"
+			return nil
+		}, 1)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, "first+mine", resource.value)
+		assert.Nil(t, emptyClient.response)
+		assert.Equal(t, "", emptyClient.header.Get("Accept"))
+	})
+
+	t.Run("fail without writing when the resource can't be decoded", func(t *testing.T) {
+
+		writes := 0
+
+		// We create a Mock Server
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				writes++
+			}
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = w.Write([]byte(`<resource/>`))
+		}))
+		defer server.Close()
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+
+		calls := 0
+		_, err := emptyClient.Update(http.MethodPut, "/resource", &GenericResponse{}, func() error {
+			calls++
+			return nil
+		}, 1)
+
+		// our data is correct?
+		assert.NotNil(t, err)
+		assert.Equal(t, 0, calls)
+		assert.Equal(t, 0, writes)
+	})
+}
@@ -1,10 +1,21 @@
 module github.com/dotWicho/requist
 
-go 1.14
+go 1.18
 
 require (
 	github.com/dotWicho/logger v1.0.0
-	github.com/google/go-querystring v1.0.0
+	github.com/google/go-querystring v1.1.0
 	github.com/hashicorp/go-cleanhttp v0.5.1
 	github.com/stretchr/testify v1.6.1
+	golang.org/x/net v0.0.0-20200822124328-c89045814202
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/konsorten/go-windows-terminal-sequences v1.0.3 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/sirupsen/logrus v1.6.0 // indirect
+	golang.org/x/sys v0.0.0-20200323222414-85ca7c5b95cd // indirect
+	golang.org/x/text v0.3.0 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
 )
@@ -0,0 +1,252 @@
+package requist
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+//=== Hedged requests
+
+const (
+	// defaultHedgeDelay is used until enough latencies are sampled
+	defaultHedgeDelay = 100 * time.Millisecond
+	// defaultHedgePercentile is the latency percentile after which a hedge is sent
+	defaultHedgePercentile = 0.95
+	// defaultHedgeSamples is the number of latencies needed before the percentile applies
+	defaultHedgeSamples = 20
+	// hedgeWindow is the number of latest latencies kept
+	hedgeWindow = 100
+)
+
+// HedgePolicy defines when extra attempts are sent for a slow GET request.
+// It is safe for concurrent use and can be shared by many Requist
+type HedgePolicy struct {
+	// MaxHedges is the number of extra attempts sent besides the original one
+	MaxHedges int
+	// Delay is how long to wait before hedging while there are less than MinSamples latencies
+	Delay time.Duration
+	// Percentile of the latest latencies used as delay once MinSamples are available, ie: 0.95
+	Percentile float64
+	// MinSamples is the number of latencies needed before Percentile applies, zero always uses Delay
+	MinSamples int
+
+	mutex   sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+// NewHedgePolicy returns a HedgePolicy sending up to maxHedges extra attempts, after delay at first
+// and after the 95th percentile latency once 20 requests succeeded
+func NewHedgePolicy(maxHedges int, delay time.Duration) *HedgePolicy {
+
+	if delay <= 0 {
+		delay = defaultHedgeDelay
+	}
+
+	return &HedgePolicy{
+		MaxHedges:  maxHedges,
+		Delay:      delay,
+		Percentile: defaultHedgePercentile,
+		MinSamples: defaultHedgeSamples,
+	}
+}
+
+// HedgeDelay returns how long an attempt waits before the next one is sent
+func (h *HedgePolicy) HedgeDelay() time.Duration {
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	delay := h.Delay
+	if delay <= 0 {
+		delay = defaultHedgeDelay
+	}
+	if h.MinSamples <= 0 || len(h.samples) < h.MinSamples || h.Percentile <= 0 {
+		return delay
+	}
+
+	sorted := make([]time.Duration, len(h.samples))
+	copy(sorted, h.samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	index := int(h.Percentile*float64(len(sorted))+0.5) - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(sorted) {
+		index = len(sorted) - 1
+	}
+	return sorted[index]
+}
+
+// observe stores the latency of a successful attempt
+func (h *HedgePolicy) observe(latency time.Duration) {
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if len(h.samples) < hedgeWindow {
+		h.samples = append(h.samples, latency)
+		return
+	}
+	h.samples[h.next] = latency
+	h.next = (h.next + 1) % hedgeWindow
+}
+
+// attempts returns the total number of attempts allowed
+func (h *HedgePolicy) attempts() int {
+
+	if h.MaxHedges < 0 {
+		return 1
+	}
+	return h.MaxHedges + 1
+}
+
+// hedgeAttempt holds a single attempt of a hedged request
+type hedgeAttempt struct {
+	response *http.Response
+	err      error
+	chain    *redirectChain
+	cancel   context.CancelFunc
+	started  time.Time
+}
+
+// succeeded tells if the attempt can be returned to the caller
+func (a *hedgeAttempt) succeeded() bool {
+
+	return a.err == nil && a.response.StatusCode < http.StatusInternalServerError
+}
+
+// discard cancels the attempt, draining and closing its body so the connection can be reused
+func (a *hedgeAttempt) discard() {
+
+	a.cancel()
+	if a.response != nil {
+		_, _ = io.Copy(ioutil.Discard, a.response.Body)
+		_ = a.response.Body.Close()
+	}
+}
+
+//=== Requist integration
+
+// SetClientHedging take policy param and hedge GET requests with it, nil disables it.
+// Only enable it for idempotent requests to replicated services
+func (r *Requist) SetClientHedging(policy *HedgePolicy) {
+
+	Logger.Debug("Setting Client Hedging %+v", policy)
+
+	r.hedging = policy
+}
+
+// hedge sends uri, and extra attempts whenever the previous ones are slow or fail, returning the first
+// successful response and canceling the others. Attempts are sent with a copy of r, as the losers
+// may still be running once the request returns
+func (r *Requist) hedge(ctx context.Context, uri string, payload []byte) (*http.Response, error) {
+
+	client := r.snapshot()
+	policy := r.hedging
+	attempts := policy.attempts()
+	delay := policy.HedgeDelay()
+	results := make(chan *hedgeAttempt, attempts)
+
+	var running []*hedgeAttempt
+	launch := func() {
+		attemptCtx, cancel := context.WithCancel(ctx)
+		attempt := &hedgeAttempt{chain: &redirectChain{}, cancel: cancel, started: time.Now()}
+		running = append(running, attempt)
+
+		if len(running) > 1 {
+			Logger.Debug("Hedging Request to %s, attempt %d", uri, len(running))
+		}
+		go func() {
+			attempt.response, attempt.err = client.roundTrip(withRedirectChain(attemptCtx, attempt.chain), uri, payload)
+			results <- attempt
+		}()
+	}
+
+	// A single timer is active at any time, it fires the next attempt
+	timer := time.NewTimer(delay)
+	defer func() { timer.Stop() }()
+	schedule := func() {
+		timer.Stop()
+		if len(running) < attempts {
+			timer = time.NewTimer(delay)
+		}
+	}
+
+	launch()
+
+	var last *hedgeAttempt
+	for pending := 1; pending > 0; {
+		select {
+		case <-timer.C:
+			if len(running) < attempts {
+				launch()
+				pending++
+				schedule()
+			}
+
+		case attempt := <-results:
+			pending--
+			if attempt.succeeded() {
+				policy.observe(time.Since(attempt.started))
+				if last != nil {
+					last.discard()
+				}
+				r.settleHedge(ctx, attempt, running, results, pending)
+				return attempt.response, nil
+			}
+
+			// Keep the last failure, in case every attempt fails
+			if last != nil {
+				last.discard()
+			}
+			last = attempt
+
+			// Don't wait for the timer, a failed attempt is replaced right away
+			if len(running) < attempts && ctx.Err() == nil {
+				launch()
+				pending++
+				schedule()
+			}
+		}
+	}
+
+	r.settleHedge(ctx, last, running, results, 0)
+	return last.response, last.err
+}
+
+// settleHedge hands winner to the caller and cancels the other attempts, cleaning up the pending ones
+// in background
+func (r *Requist) settleHedge(ctx context.Context, winner *hedgeAttempt, running []*hedgeAttempt, results chan *hedgeAttempt, pending int) {
+
+	if chain, ok := ctx.Value(redirectChainKey{}).(*redirectChain); ok {
+		for _, uri := range winner.chain.list() {
+			chain.add(uri)
+		}
+	}
+
+	// Winner context lives until its body is closed
+	if winner.response != nil {
+		winner.response.Body = &releaser{ReadCloser: winner.response.Body, release: winner.cancel}
+	} else {
+		winner.cancel()
+	}
+
+	for _, attempt := range running {
+		if attempt != winner {
+			attempt.cancel()
+		}
+	}
+
+	go func() {
+		for ; pending > 0; pending-- {
+			(<-results).discard()
+		}
+	}()
+}
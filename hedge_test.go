@@ -0,0 +1,211 @@
+package requist
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// MockHedgedServer answers the first request after slow (or once it's canceled), and the next ones right away
+func MockHedgedServer(slow time.Duration, requests, canceled *int32) *httptest.Server {
+
+	return httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name := "fast"
+			if atomic.AddInt32(requests, 1) == 1 {
+				name = "slow"
+				select {
+				case <-time.After(slow):
+				case <-r.Context().Done():
+					atomic.AddInt32(canceled, 1)
+					return
+				}
+			}
+			w.Header().Set("Content-Type", JSONContentType)
+			_, _ = w.Write([]byte(`{"result": "` + name + `"}`))
+		}),
+	)
+}
+
+// slowSigner takes slow to sign the first request, so its attempt loses
+type slowSigner struct {
+	slow  time.Duration
+	calls int32
+}
+
+func (s *slowSigner) Sign(request *http.Request, body []byte) error {
+
+	if atomic.AddInt32(&s.calls, 1) == 1 {
+		time.Sleep(s.slow)
+	}
+	return nil
+}
+
+func TestRequist_SetClientHedging(t *testing.T) {
+
+	t.Run("take the hedge when first attempt is slow", func(t *testing.T) {
+
+		var requests, canceled int32
+
+		// We create a Mock Server
+		server := MockHedgedServer(2*time.Second, &requests, &canceled)
+		defer server.Close()
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.SetClientHedging(NewHedgePolicy(1, 20*time.Millisecond))
+		emptyClient.Accept(JSONContentType)
+
+		start := time.Now()
+		success := &GenericResponse{}
+		_, err := emptyClient.Get("/", success, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, "fast", success.Result)
+		assert.True(t, time.Since(start) < time.Second)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+		// the loser must be canceled
+		assert.Eventually(t, func() bool { return atomic.LoadInt32(&canceled) == 1 }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("don't hedge fast responses", func(t *testing.T) {
+
+		var requests, canceled int32
+
+		// We create a Mock Server
+		server := MockHedgedServer(0, &requests, &canceled)
+		defer server.Close()
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.SetClientHedging(NewHedgePolicy(2, time.Second))
+		emptyClient.Accept(JSONContentType)
+
+		success := &GenericResponse{}
+		_, err := emptyClient.Get("/", success, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, "slow", success.Result)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("replace failed attempts right away", func(t *testing.T) {
+
+		var requests int32
+
+		// We create a Mock Server failing the first request
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&requests, 1) == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Header().Set("Content-Type", JSONContentType)
+			_, _ = w.Write([]byte(`{"result": "ok"}`))
+		}))
+		defer server.Close()
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.SetClientHedging(NewHedgePolicy(1, time.Minute))
+		emptyClient.Accept(JSONContentType)
+
+		success := &GenericResponse{}
+		_, err := emptyClient.Get("/", success, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, "ok", success.Result)
+		assert.Equal(t, http.StatusOK, emptyClient.StatusCode())
+	})
+
+	t.Run("return last failure when every attempt fails", func(t *testing.T) {
+
+		// We create a Mock Server
+		server := MockNamedServer("broken", http.StatusServiceUnavailable)
+		defer server.Close()
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.SetClientHedging(NewHedgePolicy(2, time.Millisecond))
+
+		_, err := emptyClient.Get("/", nil, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, emptyClient.StatusCode())
+	})
+
+	t.Run("only hedge GET requests", func(t *testing.T) {
+
+		var requests, canceled int32
+
+		// We create a Mock Server
+		server := MockHedgedServer(100*time.Millisecond, &requests, &canceled)
+		defer server.Close()
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.SetClientHedging(NewHedgePolicy(1, time.Millisecond))
+
+		_, err := emptyClient.Post("/", nil, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("let the client be set while losers finish", func(t *testing.T) {
+
+		var requests, canceled int32
+
+		// We create a Mock Server
+		server := MockHedgedServer(2*time.Second, &requests, &canceled)
+		defer server.Close()
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.SetClientHedging(NewHedgePolicy(1, 20*time.Millisecond))
+		emptyClient.SetClientCircuitBreaker(NewCircuitBreaker())
+		emptyClient.SetClientThrottler(NewThrottler())
+		emptyClient.SetSigner(&slowSigner{slow: 100 * time.Millisecond})
+
+		_, err := emptyClient.Get("/", nil, nil)
+		assert.Nil(t, err)
+
+		// Run with -race, losers must not read the client being set
+		emptyClient.SetClientCircuitBreaker(nil)
+		emptyClient.SetClientThrottler(nil)
+		emptyClient.SetClientTimeout(time.Second)
+		emptyClient.SetHeader("X-Client", "set")
+
+		// our data is correct?
+		assert.Eventually(t, func() bool { return atomic.LoadInt32(&canceled) == 1 }, time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestHedgePolicy_HedgeDelay(t *testing.T) {
+
+	policy := NewHedgePolicy(1, 50*time.Millisecond)
+	policy.MinSamples = 10
+	policy.Percentile = 0.9
+
+	// our data is correct?
+	assert.Equal(t, 50*time.Millisecond, policy.HedgeDelay())
+
+	for i := 1; i <= 10; i++ {
+		policy.observe(time.Duration(i) * time.Millisecond)
+	}
+	assert.Equal(t, 9*time.Millisecond, policy.HedgeDelay())
+
+	// older samples go away
+	for i := 0; i < hedgeWindow; i++ {
+		policy.observe(time.Second)
+	}
+	assert.Equal(t, time.Second, policy.HedgeDelay())
+}
@@ -0,0 +1,181 @@
+package requist
+
+import (
+	"context"
+	"io"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//=== Client side rate and concurrency limiting
+
+// Limiter combines a token bucket rate limiter with a max in flight semaphore.
+// It is safe for concurrent use and can be shared by many Requist
+type Limiter struct {
+	// waiting goes first, so it is 64-bit aligned for atomic operations
+	waiting int64
+	mutex   sync.Mutex
+	rate    float64
+	burst   float64
+	tokens  float64
+	last    time.Time
+	slots   chan struct{}
+	clock   func() time.Time
+}
+
+// NewLimiter returns a Limiter allowing rate requests per second with burst, and up to maxInFlight
+// concurrent requests. Zero rate or maxInFlight means unlimited
+func NewLimiter(rate float64, burst int, maxInFlight int) *Limiter {
+
+	if burst < 1 {
+		burst = 1
+	}
+
+	l := &Limiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		clock:  time.Now,
+	}
+	if maxInFlight > 0 {
+		l.slots = make(chan struct{}, maxInFlight)
+	}
+	l.last = l.clock()
+	return l
+}
+
+// Waiting returns how many requests are queued waiting for a token or a slot
+func (l *Limiter) Waiting() int {
+
+	return int(atomic.LoadInt64(&l.waiting))
+}
+
+// InFlight returns how many requests are holding a slot
+func (l *Limiter) InFlight() int {
+
+	return len(l.slots)
+}
+
+// Wait blocks until a request can be sent or ctx is done, release must be called once the request finishes
+func (l *Limiter) Wait(ctx context.Context) (release func(), err error) {
+
+	atomic.AddInt64(&l.waiting, 1)
+	defer atomic.AddInt64(&l.waiting, -1)
+
+	if err = l.take(ctx); err != nil {
+		return nil, err
+	}
+
+	if l.slots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	var once sync.Once
+	return func() { once.Do(func() { <-l.slots }) }, nil
+}
+
+// take reserves a token, sleeping until it's available
+func (l *Limiter) take(ctx context.Context) error {
+
+	if l.rate <= 0 {
+		return nil
+	}
+
+	l.mutex.Lock()
+	now := l.clock()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens--
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mutex.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Give back our reservation
+		l.mutex.Lock()
+		l.tokens++
+		l.mutex.Unlock()
+		return ctx.Err()
+	}
+}
+
+// releaser calls release once the response body is closed
+type releaser struct {
+	io.ReadCloser
+	release func()
+}
+
+// Close implements io.Closer interface
+func (r *releaser) Close() error {
+
+	err := r.ReadCloser.Close()
+	r.release()
+	return err
+}
+
+//=== Requist integration
+
+// SetClientLimiter take limiter param and apply it to every request, nil disables it
+func (r *Requist) SetClientLimiter(limiter *Limiter) {
+
+	Logger.Debug("Setting Client Limiter")
+
+	r.limiter = limiter
+}
+
+// SetClientHostLimiter take host (as in URL host, ie: api.example.com:8443) and limiter params,
+// and apply it to requests sent to that host, nil removes it
+func (r *Requist) SetClientHostLimiter(host string, limiter *Limiter) {
+
+	Logger.Debug("Setting Client Limiter for %s", host)
+
+	if r.hostLimiters == nil {
+		r.hostLimiters = map[string]*Limiter{}
+	}
+	if limiter == nil {
+		delete(r.hostLimiters, host)
+		return
+	}
+	r.hostLimiters[host] = limiter
+}
+
+// limit waits for client and host limiters, returning a func that frees their slots
+func (r *Requist) limit(ctx context.Context, host string) (func(), error) {
+
+	var releases []func()
+	release := func() {
+		for _, fn := range releases {
+			fn()
+		}
+	}
+
+	for _, limiter := range []*Limiter{r.limiter, r.hostLimiters[host]} {
+		if limiter == nil {
+			continue
+		}
+		fn, err := limiter.Wait(ctx)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		releases = append(releases, fn)
+	}
+	return release, nil
+}
@@ -0,0 +1,121 @@
+package requist
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRequist_SetClientLimiter(t *testing.T) {
+
+	t.Run("respect requests rate", func(t *testing.T) {
+
+		// We create a Mock Server
+		server := MockHTTPServer()
+		defer server.Close()
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.SetClientLimiter(NewLimiter(20, 1, 0))
+
+		start := time.Now()
+		for i := 0; i < 5; i++ {
+			_, err := emptyClient.Get("/user", nil, nil)
+			assert.Nil(t, err)
+		}
+
+		// first one is free, next 4 need 50ms each
+		assert.True(t, time.Since(start) >= 190*time.Millisecond)
+	})
+
+	t.Run("respect max in flight across goroutines", func(t *testing.T) {
+
+		var current, peak int64
+		unblock := make(chan struct{})
+
+		// We create a Mock Server that blocks until unblock is closed
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			now := atomic.AddInt64(&current, 1)
+			for {
+				old := atomic.LoadInt64(&peak)
+				if now <= old || atomic.CompareAndSwapInt64(&peak, old, now) {
+					break
+				}
+			}
+			<-unblock
+			atomic.AddInt64(&current, -1)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		limiter := NewLimiter(0, 0, 2)
+
+		var group sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			group.Add(1)
+			go func() {
+				defer group.Done()
+
+				// We create our requist Client sharing the limiter
+				client := New(server.URL)
+				client.SetClientLimiter(limiter)
+				_, err := client.Get("/", nil, nil)
+				assert.Nil(t, err)
+			}()
+		}
+
+		// wait until two requests reach the server and two are queued
+		for atomic.LoadInt64(&current) < 2 || limiter.Waiting() < 2 {
+			time.Sleep(time.Millisecond)
+		}
+
+		// our data is correct?
+		assert.EqualValues(t, 2, limiter.InFlight())
+		close(unblock)
+		group.Wait()
+
+		assert.EqualValues(t, 2, atomic.LoadInt64(&peak))
+		assert.EqualValues(t, 0, limiter.InFlight())
+	})
+}
+
+func TestRequist_SetClientHostLimiter(t *testing.T) {
+
+	// We create a Mock Server
+	server := MockHTTPServer()
+	defer server.Close()
+
+	limiter := NewLimiter(0, 0, 1)
+
+	// We create our requist Client
+	emptyClient := New(server.URL)
+	emptyClient.SetClientHostLimiter(server.Listener.Addr().String(), limiter)
+
+	// We hold the only slot available
+	release, err := limiter.Wait(context.Background())
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	emptyClient.SetClientContext(ctx)
+
+	_, err = emptyClient.Get("/user", nil, nil)
+
+	// our data is correct?
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.True(t, errors.Is(err, ErrCanceled))
+
+	release()
+	emptyClient.SetClientContext(context.Background())
+	_, err = emptyClient.Get("/user", nil, nil)
+
+	// our data is correct?
+	assert.Nil(t, err)
+	assert.EqualValues(t, 0, limiter.InFlight())
+}
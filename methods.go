@@ -0,0 +1,75 @@
+package requist
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+//=== HTTP methods
+
+// ErrInvalidMethod is returned when the method set isn't an RFC 7230 token
+var ErrInvalidMethod = errors.New("invalid HTTP method")
+
+// standardMethods are matched case insensitively, any other method is sent as is
+var standardMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodConnect,
+	http.MethodOptions,
+	http.MethodTrace,
+}
+
+// idempotentMethods can be sent again without changing the outcome, ie: failing over to another endpoint
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// normalizeMethod returns the standard method matching method, or method itself when it's an extension one
+func normalizeMethod(method string) string {
+
+	for _, standard := range standardMethods {
+		if strings.EqualFold(method, standard) {
+			return standard
+		}
+	}
+	return method
+}
+
+// isToken tells if method is an RFC 7230 token: 1*tchar
+func isToken(method string) bool {
+
+	if method == "" {
+		return false
+	}
+	for i := 0; i < len(method); i++ {
+		c := method[i]
+		if !(('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasBody tells if a response with status to a method request carries a body (RFC 7230 section 3.3.3)
+func hasBody(method string, status int) bool {
+
+	switch {
+	case method == http.MethodHead:
+		return false
+	case status >= 100 && status < 200:
+		return false
+	case status == http.StatusNoContent || status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+package requist
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+//=== Public key pinning
+
+// pinPrefix is the optional algorithm prefix accepted on pins (ie: "sha256/AbCd...=")
+const pinPrefix string = "sha256/"
+
+// ErrPinMismatch is returned when no certificate presented by the server matches our pins
+var ErrPinMismatch = errors.New("public key pin mismatch")
+
+// PinError describes a failed pin verification
+type PinError struct {
+	// Subject is the common name of the leaf certificate presented
+	Subject string
+	// Presented holds the pins of every certificate presented by the server
+	Presented []string
+}
+
+// Error implements error interface
+func (e *PinError) Error() string {
+
+	return fmt.Sprintf("%s for %s: server presented [%s]", ErrPinMismatch, e.Subject, strings.Join(e.Presented, ", "))
+}
+
+// Unwrap allows errors.Is(err, ErrPinMismatch)
+func (e *PinError) Unwrap() error {
+
+	return ErrPinMismatch
+}
+
+// PublicKeyPin returns the base64 SHA-256 hash of the certificate Subject Public Key Info
+func PublicKeyPin(certificate *x509.Certificate) string {
+
+	sum := sha256.Sum256(certificate.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// SetClientPublicKeyPins take pins param and require that leaf, intermediate or root certificates
+// presented by the server match any of them. Extra pins act as backups while rotating keys, an empty list disables pinning.
+// Without chain verification (ie: InsecureSkipVerify) only the leaf certificate may match.
+// Pins replace the VerifyConnection of the current TLS configuration, and are kept when it's replaced
+// with SetClientTLSConfig
+func (r *Requist) SetClientPublicKeyPins(pins ...string) error {
+
+	if len(pins) == 0 {
+		r.pins = nil
+		r.tlsConfig().VerifyConnection = nil
+		return nil
+	}
+
+	allowed := make(map[string]bool, len(pins))
+	for _, pin := range pins {
+		pin = strings.TrimPrefix(pin, pinPrefix)
+		if raw, err := base64.StdEncoding.DecodeString(pin); err != nil || len(raw) != sha256.Size {
+			return fmt.Errorf("invalid public key pin %q", pin)
+		}
+		allowed[pin] = true
+	}
+
+	Logger.Debug("Setting Client Public Key Pins %v", pins)
+
+	r.pins = allowed
+	r.tlsConfig().VerifyConnection = verifyPins(allowed, nil)
+	return nil
+}
+
+// verifyPins returns a tls.Config VerifyConnection, running on every handshake resumed ones included,
+// checking the connection against allowed pins once verify, if any, accepts it
+func verifyPins(allowed map[string]bool, verify func(tls.ConnectionState) error) func(tls.ConnectionState) error {
+
+	return func(state tls.ConnectionState) error {
+
+		if verify != nil {
+			if err := verify(state); err != nil {
+				return err
+			}
+		}
+
+		var certificates []*x509.Certificate
+		for _, chain := range state.VerifiedChains {
+			certificates = append(certificates, chain...)
+		}
+
+		// Without verified chains (ie: InsecureSkipVerify) anything may be appended to the leaf
+		if len(certificates) == 0 && len(state.PeerCertificates) > 0 {
+			certificates = state.PeerCertificates[:1]
+		}
+
+		presented := make([]string, 0, len(certificates))
+		for _, certificate := range certificates {
+			pin := PublicKeyPin(certificate)
+			if allowed[pin] {
+				return nil
+			}
+			presented = append(presented, pin)
+		}
+
+		subject := ""
+		if len(certificates) > 0 {
+			subject = certificates[0].Subject.CommonName
+		}
+		return &PinError{Subject: subject, Presented: presented}
+	}
+}
@@ -0,0 +1,124 @@
+package requist
+
+import (
+	"crypto/tls"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRequist_SetClientPublicKeyPins(t *testing.T) {
+
+	pki := newMockPKI(t)
+
+	// We create a Mock Server
+	server := MockTLSServer(pki, tls.NoClientCert, "127.0.0.1")
+	defer server.Close()
+
+	leafPin := PublicKeyPin(server.Certificate())
+	caPin := PublicKeyPin(pki.ca)
+	otherPin := PublicKeyPin(newMockPKI(t).ca)
+
+	t.Run("fail with invalid pin", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+
+		// our data is correct?
+		assert.NotNil(t, emptyClient.SetClientPublicKeyPins("not-a-pin"))
+	})
+
+	t.Run("success with leaf pin", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		assert.Nil(t, emptyClient.SetClientRootCAs(pki.CAPEM))
+		assert.Nil(t, emptyClient.SetClientPublicKeyPins(leafPin))
+
+		_, err := emptyClient.Get("/", nil, nil)
+
+		// if client return not Nil?
+		assert.Nil(t, err)
+	})
+
+	t.Run("success with authority pin and sha256 prefix", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		assert.Nil(t, emptyClient.SetClientRootCAs(pki.CAPEM))
+		assert.Nil(t, emptyClient.SetClientPublicKeyPins("sha256/"+caPin))
+
+		_, err := emptyClient.Get("/", nil, nil)
+
+		// if client return not Nil?
+		assert.Nil(t, err)
+	})
+
+	t.Run("success with backup pin", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		assert.Nil(t, emptyClient.SetClientRootCAs(pki.CAPEM))
+		assert.Nil(t, emptyClient.SetClientPublicKeyPins(otherPin, leafPin))
+
+		_, err := emptyClient.Get("/", nil, nil)
+
+		// if client return not Nil?
+		assert.Nil(t, err)
+	})
+
+	t.Run("fail on mismatch", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		assert.Nil(t, emptyClient.SetClientRootCAs(pki.CAPEM))
+		assert.Nil(t, emptyClient.SetClientPublicKeyPins(otherPin))
+
+		_, err := emptyClient.Get("/", nil, nil)
+
+		// our data is correct?
+		assert.True(t, errors.Is(err, ErrPinMismatch))
+
+		var pinError *PinError
+		assert.True(t, errors.As(err, &pinError))
+		assert.Contains(t, pinError.Presented, leafPin)
+		assert.Contains(t, err.Error(), leafPin)
+	})
+
+	t.Run("keep pins when TLS config is replaced", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		assert.Nil(t, emptyClient.SetClientPublicKeyPins(otherPin))
+		emptyClient.SetClientTLSConfig(&tls.Config{InsecureSkipVerify: true})
+
+		_, err := emptyClient.Get("/", nil, nil)
+
+		// our data is correct?
+		assert.True(t, errors.Is(err, ErrPinMismatch))
+	})
+
+	t.Run("only check the leaf without chain verification", func(t *testing.T) {
+
+		// We create a Mock Server presenting an attacker leaf, with the pinned authority appended
+		attacker := MockTLSServer(newMockPKI(t), tls.NoClientCert, "127.0.0.1")
+		defer attacker.Close()
+		attacker.TLS.Certificates[0].Certificate = append(attacker.TLS.Certificates[0].Certificate, pki.ca.Raw)
+
+		// We create our requist Client
+		emptyClient := New(attacker.URL)
+		emptyClient.SetClientTLSConfig(&tls.Config{InsecureSkipVerify: true})
+		assert.Nil(t, emptyClient.SetClientPublicKeyPins(caPin))
+
+		_, err := emptyClient.Get("/", nil, nil)
+
+		// our data is correct?
+		assert.True(t, errors.Is(err, ErrPinMismatch))
+
+		assert.Nil(t, emptyClient.SetClientPublicKeyPins(PublicKeyPin(attacker.Certificate())))
+		_, err = emptyClient.Get("/", nil, nil)
+
+		// if client return not Nil?
+		assert.Nil(t, err)
+	})
+}
@@ -0,0 +1,64 @@
+package requist
+
+import (
+	"fmt"
+	"golang.org/x/net/http/httpproxy"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+//=== Proxy configuration
+
+// ProxySelector returns the proxy to use for a request, nil means a direct connection
+type ProxySelector func(request *http.Request) (*url.URL, error)
+
+// isValidProxyScheme check validity of a proxy scheme
+func isValidProxyScheme(scheme string) bool {
+
+	return scheme == "http" || scheme == "https" || scheme == "socks5"
+}
+
+// SetClientProxy take proxyURL param (http, https or socks5 scheme, credentials as user:password@)
+// and route every request through it except those matching noProxy rules, with NO_PROXY syntax.
+// As with NO_PROXY, requests to localhost and loopback addresses are always sent directly
+func (r *Requist) SetClientProxy(proxyURL string, noProxy ...string) error {
+
+	proxy, err := url.Parse(proxyURL)
+	if err != nil {
+		return err
+	}
+	if !isValidProxyScheme(proxy.Scheme) || proxy.Host == "" {
+		return fmt.Errorf("invalid proxy URL %s://%s", proxy.Scheme, proxy.Host)
+	}
+
+	Logger.Debug("Setting Client Proxy %s://%s", proxy.Scheme, proxy.Host)
+
+	config := &httpproxy.Config{
+		HTTPProxy:  proxy.String(),
+		HTTPSProxy: proxy.String(),
+		NoProxy:    strings.Join(noProxy, ","),
+	}
+	proxyFunc := config.ProxyFunc()
+
+	r.SetClientProxySelector(func(request *http.Request) (*url.URL, error) {
+		return proxyFunc(request.URL)
+	})
+	return nil
+}
+
+// SetClientProxySelector take selector param and let it choose the proxy for each request, nil disables proxies
+func (r *Requist) SetClientProxySelector(selector ProxySelector) {
+
+	Logger.Debug("Setting Client Proxy Selector")
+
+	r.transport().Proxy = selector
+}
+
+// SetClientProxyFromEnvironment use HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables
+func (r *Requist) SetClientProxyFromEnvironment() {
+
+	Logger.Debug("Setting Client Proxy from Environment")
+
+	r.transport().Proxy = http.ProxyFromEnvironment
+}
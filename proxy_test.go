@@ -0,0 +1,199 @@
+package requist
+
+import (
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+// MockHTTPProxy answers every proxied request by itself, echoing target host and proxy credentials
+func MockHTTPProxy() *httptest.Server {
+
+	return httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", JSONContentType)
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"result": "` + r.URL.Host + ` ` + r.Header.Get("Proxy-Authorization") + `"}`))
+		}),
+	)
+}
+
+// MockSOCKS5Proxy is a minimal SOCKS5 server with username/password auth that routes every CONNECT to target
+func MockSOCKS5Proxy(t *testing.T, username, password, target string) (net.Listener, *[]string) {
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	requested := &[]string{}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+
+				// greeting: version, methods
+				header := make([]byte, 2)
+				if _, err := io.ReadFull(conn, header); err != nil {
+					return
+				}
+				methods := make([]byte, header[1])
+				_, _ = io.ReadFull(conn, methods)
+				_, _ = conn.Write([]byte{0x05, 0x02})
+
+				// username/password negotiation (RFC 1929)
+				_, _ = io.ReadFull(conn, header[:2])
+				user := make([]byte, header[1])
+				_, _ = io.ReadFull(conn, user)
+				_, _ = io.ReadFull(conn, header[:1])
+				pass := make([]byte, header[0])
+				_, _ = io.ReadFull(conn, pass)
+				if string(user) != username || string(pass) != password {
+					_, _ = conn.Write([]byte{0x01, 0x01})
+					return
+				}
+				_, _ = conn.Write([]byte{0x01, 0x00})
+
+				// connect request: version, cmd, reserved, address type
+				request := make([]byte, 4)
+				_, _ = io.ReadFull(conn, request)
+				var host string
+				switch request[3] {
+				case 0x01:
+					ip := make([]byte, 4)
+					_, _ = io.ReadFull(conn, ip)
+					host = net.IP(ip).String()
+				case 0x03:
+					_, _ = io.ReadFull(conn, header[:1])
+					name := make([]byte, header[0])
+					_, _ = io.ReadFull(conn, name)
+					host = string(name)
+				}
+				port := make([]byte, 2)
+				_, _ = io.ReadFull(conn, port)
+				*requested = append(*requested, net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))))
+
+				upstream, err := net.Dial("tcp", target)
+				if err != nil {
+					return
+				}
+				defer upstream.Close()
+				_, _ = conn.Write([]byte{0x05, 0x00, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+
+				go func() { _, _ = io.Copy(upstream, conn) }()
+				_, _ = io.Copy(conn, upstream)
+			}(conn)
+		}
+	}()
+	return listener, requested
+}
+
+func TestRequist_SetClientProxy(t *testing.T) {
+
+	// We create a Mock Proxy
+	proxy := MockHTTPProxy()
+	defer proxy.Close()
+
+	proxyURL, _ := url.Parse(proxy.URL)
+	proxyURL.User = url.UserPassword("jonah", "doe")
+
+	t.Run("fail with invalid proxy", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New("http://api.example")
+
+		// our data is correct?
+		assert.NotNil(t, emptyClient.SetClientProxy("ftp://proxy.example"))
+	})
+
+	t.Run("route requests through proxy with credentials", func(t *testing.T) {
+
+		success := &GenericResponse{}
+
+		// We create our requist Client
+		emptyClient := New("http://api.example")
+		emptyClient.Accept(JSONContentType)
+		assert.Nil(t, emptyClient.SetClientProxy(proxyURL.String()))
+
+		_, err := emptyClient.Get("/user", success, nil)
+
+		// if client return not Nil?
+		assert.Nil(t, err)
+
+		// our data is correct?
+		assert.EqualValues(t, "api.example Basic am9uYWg6ZG9l", success.Result)
+	})
+
+	t.Run("bypass proxy with no proxy rules", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New("http://api.example")
+		assert.Nil(t, emptyClient.SetClientProxy(proxyURL.String(), "other.example", ".example"))
+
+		request, _ := http.NewRequest(http.MethodGet, "http://api.example/user", nil)
+		selected, err := emptyClient.transport().Proxy(request)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Nil(t, selected)
+	})
+}
+
+func TestRequist_SetClientProxySelector(t *testing.T) {
+
+	// We create a Mock Proxy
+	proxy := MockHTTPProxy()
+	defer proxy.Close()
+
+	proxyURL, _ := url.Parse(proxy.URL)
+	success := &GenericResponse{}
+
+	// We create our requist Client
+	emptyClient := New("http://api.example")
+	emptyClient.Accept(JSONContentType)
+	emptyClient.SetClientProxySelector(func(request *http.Request) (*url.URL, error) {
+		return proxyURL, nil
+	})
+
+	_, err := emptyClient.Get("/user", success, nil)
+
+	// if client return not Nil?
+	assert.Nil(t, err)
+
+	// our data is correct?
+	assert.EqualValues(t, "api.example ", success.Result)
+}
+
+func TestRequist_SetClientProxy_SOCKS5(t *testing.T) {
+
+	// We create a Mock Server and a SOCKS5 proxy in front of it
+	server := MockHTTPServer()
+	defer server.Close()
+
+	listener, requested := MockSOCKS5Proxy(t, "jonah", "doe", server.Listener.Addr().String())
+	defer listener.Close()
+
+	success := &UserInfo{}
+
+	// We create our requist Client
+	emptyClient := New("http://api.example")
+	emptyClient.Accept(JSONContentType)
+	assert.Nil(t, emptyClient.SetClientProxy("socks5://jonah:doe@"+listener.Addr().String()))
+
+	_, err := emptyClient.Get("/user/1000", success, nil)
+
+	// if client return not Nil?
+	assert.Nil(t, err)
+
+	// our data is correct?
+	assert.EqualValues(t, "Jonah Doe", success.Name)
+	assert.EqualValues(t, []string{"api.example:80"}, *requested)
+}
@@ -0,0 +1,142 @@
+package requist
+
+import (
+	"github.com/google/go-querystring/query"
+	"reflect"
+	"strings"
+	"time"
+)
+
+//=== Struct based query parameters
+
+// ArrayStyle defines how slices are encoded by QueryStruct
+type ArrayStyle int
+
+const (
+	// ArrayRepeat repeats the key for every item: ids=1&ids=2
+	ArrayRepeat ArrayStyle = iota
+	// ArrayComma joins items with commas: ids=1,2
+	ArrayComma
+	// ArrayBrackets appends brackets to the key for every item: ids[]=1&ids[]=2
+	ArrayBrackets
+)
+
+// encoderType is the go-querystring interface of types encoding themselves
+var encoderType = reflect.TypeOf(new(query.Encoder)).Elem()
+
+// SetArrayStyle sets how QueryStruct encodes slices without their own style option, ArrayRepeat by default
+func (r *Requist) SetArrayStyle(style ArrayStyle) *Requist {
+
+	r.arrayStyle = style
+
+	return r
+}
+
+// QueryStruct encodes v, a struct with url tags, into QueryParams replacing keys already set.
+// Tags follow go-querystring: omitempty, nested structs as parent[child], time.Time with a layout:"..." tag
+// or unix, unixmilli and unixnano options, and comma, space, semicolon, brackets or numbered options
+// (or a del:"..." tag) to encode a single slice.
+// Slices without any of those options use the style set by SetArrayStyle
+func (r *Requist) QueryStruct(v interface{}) (*Requist, error) {
+
+	values, err := query.Values(v)
+	if err != nil {
+		return r, err
+	}
+
+	if r.arrayStyle != ArrayRepeat {
+		val := reflect.ValueOf(v)
+		for val.Kind() == reflect.Ptr && !val.IsNil() {
+			val = val.Elem()
+		}
+		if val.Kind() == reflect.Struct {
+			for _, key := range arrayKeys(val, "") {
+				items, ok := values[key]
+				if !ok {
+					continue
+				}
+				switch r.arrayStyle {
+				case ArrayComma:
+					values[key] = []string{strings.Join(items, ",")}
+				case ArrayBrackets:
+					delete(values, key)
+					values[key+"[]"] = items
+				}
+			}
+		}
+	}
+
+	for key, items := range values {
+		(*r.queries)[key] = items
+	}
+
+	Logger.Debug("Setting QueryParams from (%T)", v)
+
+	return r, nil
+}
+
+// arrayKeys returns the keys go-querystring uses for slices of val without their own style option
+func arrayKeys(val reflect.Value, scope string) []string {
+
+	var keys []string
+	var embedded []reflect.Value
+
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		value := val.Field(i)
+		tag := field.Tag.Get("url")
+		if tag == "-" {
+			continue
+		}
+		options := strings.Split(tag, ",")
+		name := options[0]
+		if name == "" {
+			if field.Anonymous {
+				if indirect := reflect.Indirect(value); indirect.IsValid() && indirect.Kind() == reflect.Struct {
+					embedded = append(embedded, indirect)
+					continue
+				}
+			}
+			name = field.Name
+		}
+		if scope != "" {
+			name = scope + "[" + name + "]"
+		}
+
+		if value.Type().Implements(encoderType) {
+			continue
+		}
+
+		for value.Kind() == reflect.Ptr && !value.IsNil() {
+			value = value.Elem()
+		}
+
+		if value.Kind() == reflect.Slice || value.Kind() == reflect.Array {
+			styled := field.Tag.Get("del") != ""
+			for _, option := range options[1:] {
+				switch option {
+				case "comma", "space", "semicolon", "brackets", "numbered":
+					styled = true
+				}
+			}
+			if !styled {
+				keys = append(keys, name)
+			}
+			continue
+		}
+
+		if value.Kind() == reflect.Struct && value.Type() != reflect.TypeOf(time.Time{}) {
+			keys = append(keys, arrayKeys(value, name)...)
+		}
+	}
+
+	for _, value := range embedded {
+		keys = append(keys, arrayKeys(value, scope)...)
+	}
+	return keys
+}
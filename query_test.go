@@ -0,0 +1,128 @@
+package requist
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// SearchFilter is a nested struct encoded by QueryStruct
+type SearchFilter struct {
+	Owner  string   `url:"owner,omitempty"`
+	Labels []string `url:"labels"`
+}
+
+// SearchQuery is the struct encoded by QueryStruct tests
+type SearchQuery struct {
+	Term    string       `url:"q"`
+	Page    int          `url:"page,omitempty"`
+	IDs     []int        `url:"ids"`
+	Tags    []string     `url:"tags,comma"`
+	Since   time.Time    `url:"since" layout:"2006-01-02"`
+	Until   time.Time    `url:"until,unix"`
+	Filter  SearchFilter `url:"filter"`
+	Ignored string       `url:"-"`
+}
+
+func TestRequist_QueryStruct(t *testing.T) {
+
+	search := &SearchQuery{
+		Term:   "requist",
+		IDs:    []int{1, 2},
+		Tags:   []string{"go", "http"},
+		Since:  time.Date(2020, 8, 20, 0, 0, 0, 0, time.UTC),
+		Until:  time.Unix(1600000000, 0),
+		Filter: SearchFilter{Labels: []string{"bug"}},
+	}
+
+	tests := []struct {
+		name     string
+		style    ArrayStyle
+		expected string
+	}{
+		{
+			"repeat keys",
+			ArrayRepeat,
+			"filter%5Blabels%5D=bug&ids=1&ids=2&q=requist&since=2020-08-20&tags=go%2Chttp&until=1600000000",
+		},
+		{
+			"join with commas",
+			ArrayComma,
+			"filter%5Blabels%5D=bug&ids=1%2C2&q=requist&since=2020-08-20&tags=go%2Chttp&until=1600000000",
+		},
+		{
+			"append brackets",
+			ArrayBrackets,
+			"filter%5Blabels%5D%5B%5D=bug&ids%5B%5D=1&ids%5B%5D=2&q=requist&since=2020-08-20&tags=go%2Chttp&until=1600000000",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			// We create our requist Client
+			emptyClient := New("http://live.apitest.org")
+			emptyClient.SetArrayStyle(test.style)
+
+			_, err := emptyClient.QueryStruct(search)
+
+			// our data is correct?
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, emptyClient.queries.Encode())
+		})
+	}
+
+	t.Run("replace keys already set", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New("http://live.apitest.org")
+		emptyClient.SetQueryParam("q", "old")
+		emptyClient.SetQueryParam("keep", "yes")
+
+		_, err := emptyClient.QueryStruct(struct {
+			Term string `url:"q"`
+		}{"new"})
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, "keep=yes&q=new", emptyClient.queries.Encode())
+	})
+
+	t.Run("fail with invalid values", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New("http://live.apitest.org")
+
+		_, err := emptyClient.QueryStruct("q=requist")
+
+		// our data is correct?
+		assert.NotNil(t, err)
+	})
+
+	t.Run("send encoded params", func(t *testing.T) {
+
+		var received string
+
+		// We create a Mock Server
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r.URL.RawQuery
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		// We create our requist Client
+		emptyClient := New(server.URL).SetArrayStyle(ArrayComma)
+		_, err := emptyClient.QueryStruct(struct {
+			IDs []int `url:"ids"`
+		}{[]int{7, 8}})
+		assert.Nil(t, err)
+
+		_, err = emptyClient.Get("/search", nil, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, "ids=7%2C8", received)
+	})
+}
@@ -0,0 +1,122 @@
+package requist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+//=== Redirects handling
+
+// defaultMaxRedirects is the same cap used by http.Client
+const defaultMaxRedirects = 10
+
+var (
+	// ErrTooManyRedirects is returned when a request exceeds RedirectPolicy.MaxRedirects
+	ErrTooManyRedirects = errors.New("too many redirects")
+	// ErrRedirectNotAllowed is returned when a redirect breaks RedirectPolicy host or scheme restrictions
+	ErrRedirectNotAllowed = errors.New("redirect not allowed")
+)
+
+// RedirectPolicy defines how Requist follows HTTP redirects
+type RedirectPolicy struct {
+	// Disable stops following redirects, the 3xx response is returned as is
+	Disable bool
+	// MaxRedirects caps the redirects followed by request, 10 if zero
+	MaxRedirects int
+	// SameHost only follows redirects to the host of the original request
+	SameHost bool
+	// SameScheme only follows redirects keeping the scheme of the original request
+	SameScheme bool
+	// StripAuthorization removes the Authorization header on cross-origin redirects
+	StripAuthorization bool
+}
+
+// redirectChainKey is the context key holding the redirectChain of a request
+type redirectChainKey struct{}
+
+// redirectChain records the redirects followed by a request
+type redirectChain struct {
+	mutex sync.Mutex
+	urls  []string
+}
+
+// withRedirectChain returns a copy of ctx carrying chain
+func withRedirectChain(ctx context.Context, chain *redirectChain) context.Context {
+
+	return context.WithValue(ctx, redirectChainKey{}, chain)
+}
+
+// add appends uri to the chain
+func (c *redirectChain) add(uri string) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.urls = append(c.urls, uri)
+}
+
+// list returns the redirects recorded
+func (c *redirectChain) list() []string {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.urls
+}
+
+// SetClientRedirectPolicy take policy param and set how redirects are followed, nil restores defaults
+func (r *Requist) SetClientRedirectPolicy(policy *RedirectPolicy) {
+
+	Logger.Debug("Setting Client RedirectPolicy %+v", policy)
+
+	r.redirectPolicy = policy
+}
+
+// Redirects return the redirect chain followed by last request
+func (r *Requist) Redirects() []string {
+
+	return r.redirects
+}
+
+// checkRedirect implements http.Client CheckRedirect, recording the chain and applying our policy
+func (r *Requist) checkRedirect(request *http.Request, via []*http.Request) error {
+
+	policy := r.redirectPolicy
+	if policy == nil {
+		policy = &RedirectPolicy{}
+	}
+
+	if policy.Disable {
+		return http.ErrUseLastResponse
+	}
+
+	max := policy.MaxRedirects
+	if max <= 0 {
+		max = defaultMaxRedirects
+	}
+	if len(via) > max {
+		return fmt.Errorf("%w: stopped after %d", ErrTooManyRedirects, max)
+	}
+
+	original := via[0].URL
+	if policy.SameHost && request.URL.Host != original.Host {
+		return fmt.Errorf("%w: host %s differs from %s", ErrRedirectNotAllowed, request.URL.Host, original.Host)
+	}
+	if policy.SameScheme && request.URL.Scheme != original.Scheme {
+		return fmt.Errorf("%w: scheme %s differs from %s", ErrRedirectNotAllowed, request.URL.Scheme, original.Scheme)
+	}
+
+	if policy.StripAuthorization && (request.URL.Scheme != original.Scheme || request.URL.Host != original.Host) {
+		request.Header.Del("Authorization")
+	}
+
+	if chain, ok := request.Context().Value(redirectChainKey{}).(*redirectChain); ok {
+		chain.add(request.URL.String())
+	}
+	Logger.Debug("Following redirect to %s", request.URL)
+
+	return nil
+}
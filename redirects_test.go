@@ -0,0 +1,121 @@
+package requist
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// MockRedirectServer redirects /hop/{n} down to /final, /away to other host and echoes Authorization on /final
+func MockRedirectServer(other string) *httptest.Server {
+
+	return httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/hop/3":
+				http.Redirect(w, r, "/hop/2", http.StatusFound)
+			case "/hop/2":
+				http.Redirect(w, r, "/hop/1", http.StatusFound)
+			case "/hop/1":
+				http.Redirect(w, r, "/final", http.StatusFound)
+			case "/away":
+				http.Redirect(w, r, other+"/final", http.StatusFound)
+			case "/final":
+				w.Header().Set("Content-Type", JSONContentType)
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(`{"result": "` + r.Header.Get("Authorization") + `"}`))
+			}
+		}),
+	)
+}
+
+func TestRequist_SetClientRedirectPolicy(t *testing.T) {
+
+	// We create a Mock Servers
+	other := MockRedirectServer("")
+	defer other.Close()
+	server := MockRedirectServer(other.URL)
+	defer server.Close()
+
+	t.Run("follow and record redirects by default", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+
+		_, err := emptyClient.Get("/hop/3", nil, nil)
+
+		// if client return not Nil?
+		assert.Nil(t, err)
+
+		// our data is correct?
+		assert.EqualValues(t, http.StatusOK, emptyClient.StatusCode())
+		assert.EqualValues(t, []string{server.URL + "/hop/2", server.URL + "/hop/1", server.URL + "/final"}, emptyClient.Redirects())
+	})
+
+	t.Run("return 3xx when redirects are disabled", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.SetClientRedirectPolicy(&RedirectPolicy{Disable: true})
+
+		_, err := emptyClient.Get("/hop/3", nil, nil)
+
+		// if client return not Nil?
+		assert.Nil(t, err)
+
+		// our data is correct?
+		assert.EqualValues(t, http.StatusFound, emptyClient.StatusCode())
+		assert.Empty(t, emptyClient.Redirects())
+	})
+
+	t.Run("fail when exceed max redirects", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.SetClientRedirectPolicy(&RedirectPolicy{MaxRedirects: 2})
+
+		_, err := emptyClient.Get("/hop/3", nil, nil)
+
+		// our data is correct?
+		assert.True(t, errors.Is(err, ErrTooManyRedirects))
+		assert.Len(t, emptyClient.Redirects(), 2)
+	})
+
+	t.Run("fail when redirected to another host", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.SetClientRedirectPolicy(&RedirectPolicy{SameHost: true})
+
+		_, err := emptyClient.Get("/away", nil, nil)
+
+		// our data is correct?
+		assert.True(t, errors.Is(err, ErrRedirectNotAllowed))
+	})
+
+	t.Run("strip Authorization on cross-origin redirects", func(t *testing.T) {
+
+		success := &GenericResponse{}
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.SetClientRedirectPolicy(&RedirectPolicy{StripAuthorization: true})
+		emptyClient.SetBasicAuth("jonah", "doe")
+		emptyClient.Accept(JSONContentType)
+
+		_, err := emptyClient.Get("/hop/1", success, nil)
+
+		// same origin keeps our credentials
+		assert.Nil(t, err)
+		assert.NotEmpty(t, success.Result)
+
+		success = &GenericResponse{}
+		_, err = emptyClient.Get("/away", success, nil)
+
+		// cross origin drops them
+		assert.Nil(t, err)
+		assert.Empty(t, success.Result)
+	})
+}
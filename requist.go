@@ -1,15 +1,21 @@
 package requist
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/base64"
+	"errors"
+	"fmt"
 	"github.com/dotWicho/logger"
+	"io/ioutil"
 	"strings"
 
 	// We use go-cleanhttp because it contains a better implementation of http.Transport
 	// and allows us to abstract from these changes
 	"github.com/hashicorp/go-cleanhttp"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"time"
@@ -23,8 +29,39 @@ var Logger = logger.NewLogger(false)
 // Operations interface Define all Methods
 type Operations interface {
 	SetClientTransport(transport *http.Transport)
+	SetClientRoundTripper(roundTripper http.RoundTripper)
+	ClientTransport() *http.Transport
 	SetClientTimeout(timeout time.Duration)
 	SetClientContext(context context.Context)
+	WithContext(ctx context.Context) *Requist
+	Timeout(timeout time.Duration) *Requist
+	SetClientCookieJar(jar http.CookieJar)
+	SetClientRedirectPolicy(policy *RedirectPolicy)
+	SetClientTLSConfig(config *tls.Config)
+	SetClientRootCAs(bundles ...[]byte) error
+	SetClientRootCAsFromFiles(filenames ...string) error
+	SetClientCertificate(certPEM, keyPEM []byte) error
+	SetClientCertificateFromFiles(certFile, keyFile string) error
+	SetClientCertificateReloader(reloader *CertificateReloader)
+	SetClientTLSMinVersion(version uint16)
+	SetClientTLSCipherSuites(suites ...uint16)
+	SetClientTLSServerName(name string)
+	SetClientPublicKeyPins(pins ...string) error
+	SetClientProxy(proxyURL string, noProxy ...string) error
+	SetClientProxySelector(selector ProxySelector)
+	SetClientProxyFromEnvironment()
+	SetClientUnixSocket(socket string)
+	SetClientResolve(host string, addresses ...string)
+	SetClientResolver(resolver *net.Resolver)
+	SetClientDNSCache(ttl time.Duration)
+	SetClientBalancer(balancer *Balancer)
+	SetClientCircuitBreaker(breaker *CircuitBreaker)
+	SetClientLimiter(limiter *Limiter)
+	SetClientHostLimiter(host string, limiter *Limiter)
+	SetClientThrottler(throttler *Throttler)
+	SetClientHedging(policy *HedgePolicy)
+	SetClientCoalescer(coalescer *Coalescer)
+	SetClientCache(cache *Cache)
 
 	BodyProvider(body BodyProvider) *Requist
 	BodyAsForm(body interface{}) *Requist
@@ -38,26 +75,46 @@ type Operations interface {
 	AddHeader(key, value string)
 	SetHeader(key, value string)
 	DelHeader(key string)
+	AddRequestHeader(key, value string) *Requist
+	SetRequestHeader(key, value string) *Requist
 	AddQueryParam(key, value string)
 	SetQueryParam(key, value string)
 	DelQueryParam(key string)
 	CleanQueryParams()
+	AddDefaultQueryParam(key, value string)
+	SetDefaultQueryParam(key, value string)
+	DelDefaultQueryParam(key string)
+	QueryStruct(v interface{}) (*Requist, error)
+	SetArrayStyle(style ArrayStyle) *Requist
 	SetBasicAuth(username, password string) *Requist
+	SetSigner(signer Signer) *Requist
 	StatusCode() int
+	Redirects() []string
+	RateLimit() *RateLimit
+	CacheStatus() CacheStatus
+	ETag() string
+	LastModified() time.Time
+	IfMatch(etag string) *Requist
+	IfNoneMatch(etag string) *Requist
+	IfUnmodifiedSince(since time.Time) *Requist
 	GetBasicAuth() string
 
 	Base(base string) *Requist
 	Path(path string) *Requist
+	PathTemplate(template string, params interface{}) (*Requist, error)
 	URI(uri string) *Requist
 	Method(method string) *Requist
 
 	Get(path string, success, failure interface{}) (*Requist, error)
+	Head(path string, success, failure interface{}) (*Requist, error)
 	Put(path string, success, failure interface{}) (*Requist, error)
 	Post(path string, success, failure interface{}) (*Requist, error)
 	Patch(path string, success, failure interface{}) (*Requist, error)
 	Delete(path string, success, failure interface{}) (*Requist, error)
 	Options(path string, success, failure interface{}) (*Requist, error)
 	Connect(path string, success, failure interface{}) (*Requist, error)
+	Trace(path string, success, failure interface{}) (*Requist, error)
+	Update(method, path string, resource interface{}, modify func() error, attempts int) (*Requist, error)
 }
 
 // Requist struct Encapsulate an HTTP(S) requests builder and sender
@@ -69,19 +126,73 @@ type Requist struct {
 	url    string
 	uri    string
 	path   string
+	socket string
 
-	// Holds last HTTP Response Code
-	statuscode int
+	// Holds last HTTP Response Code, Headers and rate limit advertised
+	statuscode     int
+	responseHeader http.Header
+	ratelimit      *RateLimit
+
+	// Redirects followed by last request and how to follow them
+	redirects      []string
+	redirectPolicy *RedirectPolicy
+
+	// Handle HTTP(S) primitives, a custom RoundTripper replaces the client Transport while set
+	roundTripper http.RoundTripper
+	base         *http.Transport
 
-	// Handle HTTP(S) primitives
 	client  *http.Client
+	dialer  *Dialer
+	pins    map[string]bool
 	header  *http.Header
 	queries *url.Values
 	ctx     context.Context
 
+	// Context and timeout of next request only
+	requestCtx context.Context
+	timeout    time.Duration
+
+	// Query params sent with every request
+	defaultQueries url.Values
+
 	// Bodies, Request and Response
 	provider BodyProvider
 	response BodyResponse
+
+	// Signs requests before being sent
+	signer Signer
+
+	// Spreads requests between several base URLs
+	balancer *Balancer
+
+	// Stops sending requests to failing hosts
+	breaker *CircuitBreaker
+
+	// Limits requests rate and concurrency, for every host and by host
+	limiter      *Limiter
+	hostLimiters map[string]*Limiter
+
+	// Paces requests following rate limit headers
+	throttler *Throttler
+
+	// Sends extra attempts for slow GET requests
+	hedging *HedgePolicy
+
+	// Shares identical concurrent GET requests
+	coalescer *Coalescer
+
+	// Stores GET responses, and how last one was obtained
+	cache       *Cache
+	cacheStatus CacheStatus
+
+	// How QueryStruct encodes slices
+	arrayStyle ArrayStyle
+
+	// Headers sent with and decoder of next request only, and validators of last response
+	scoped         http.Header
+	scopedResponse BodyResponse
+	etag           string
+	lastModified   time.Time
 }
 
 //=== Functions to create a Requist instance
@@ -95,21 +206,23 @@ func New(baseURL string) *Requist {
 	Logger.Debug("Creating a new Client")
 	r := &Requist{}
 
-	r.url = ParseBaseURL(baseURL)
-	if r.url == "" {
+	if ParseBaseURL(baseURL) == "" && ParseUnixSocket(baseURL) == "" {
 		Logger.Error("Invalid baseURL = %s", baseURL)
 		return nil
 	}
+	r.method = http.MethodGet
 	r.header = &http.Header{}
 	r.queries = &url.Values{}
+	r.defaultQueries = url.Values{}
 	r.client = &http.Client{}
+	r.client.CheckRedirect = r.checkRedirect
 	r.ctx = context.Background()
 	r.SetClientTransport(cleanhttp.DefaultTransport())
 	r.SetClientTimeout(defaultTimeout)
 	r.provider = nil
 	r.response = nil
 
-	return r.Base(r.url)
+	return r.Base(baseURL)
 }
 
 // SetClientTransport take transport param and set client HTTP Transport
@@ -117,10 +230,59 @@ func (r *Requist) SetClientTransport(transport *http.Transport) {
 
 	Logger.Debug("Setting Client Transport %+v", transport)
 
+	if r.roundTripper != nil {
+		r.base = transport
+		return
+	}
 	r.client.Transport = transport
 }
 
-// SetClientTimeout take timeout param and set client Timeout seconds based
+// SetClientRoundTripper take roundTripper param and send requests through it instead of the client Transport
+// (ie: a mock or a recorder), nil restores the client Transport. Transport settings are kept meanwhile
+func (r *Requist) SetClientRoundTripper(roundTripper http.RoundTripper) {
+
+	Logger.Debug("Setting Client RoundTripper (%T)", roundTripper)
+
+	if r.roundTripper == nil {
+		r.base, _ = r.client.Transport.(*http.Transport)
+	}
+	r.roundTripper = roundTripper
+
+	if roundTripper == nil {
+		r.client.Transport = r.base
+		r.base = nil
+		return
+	}
+	r.client.Transport = roundTripper
+}
+
+// ClientTransport returns the client HTTP Transport, the one used again once a custom RoundTripper is removed,
+// so RoundTrippers like recorders can send requests through it
+func (r *Requist) ClientTransport() *http.Transport {
+
+	return r.transport()
+}
+
+// transport returns the client HTTP Transport, creating a default one if missing
+func (r *Requist) transport() *http.Transport {
+
+	// Settings go to the Transport restored once the custom RoundTripper is removed
+	if r.roundTripper != nil {
+		if r.base == nil {
+			r.base = cleanhttp.DefaultTransport()
+		}
+		return r.base
+	}
+
+	transport, ok := r.client.Transport.(*http.Transport)
+	if !ok || transport == nil {
+		transport = cleanhttp.DefaultTransport()
+		r.client.Transport = transport
+	}
+	return transport
+}
+
+// SetClientTimeout take timeout param and set client Timeout seconds based, bounding every single attempt
 func (r *Requist) SetClientTimeout(timeout time.Duration) {
 
 	Logger.Debug("Setting Client Timeout %+v", timeout)
@@ -128,7 +290,7 @@ func (r *Requist) SetClientTimeout(timeout time.Duration) {
 	r.client.Timeout = timeout
 }
 
-// SetClientContext take transport param and set client HTTP Transport
+// SetClientContext take context param and set it as the context of every request
 func (r *Requist) SetClientContext(context context.Context) {
 
 	Logger.Debug("Setting Client Context %+v", context)
@@ -145,53 +307,131 @@ func (r *Requist) Request(success, failure interface{}) (*Requist, error) {
 	var requestPath string
 	var err error
 
+	// Headers, query params and body set for this request only, client level defaults are kept
+	scoped := r.scoped
+	r.scoped = nil
+	scopedResponse := r.scopedResponse
+	r.scopedResponse = nil
+	provider := r.provider
+	r.provider = nil
+	r.responseHeader = nil
+	defer r.CleanQueryParams()
+	requestCtx, cancel := r.requestContext()
+	defer cancel()
+
+	// Explicit URIs are sent as is, everything else may be balanced between endpoints
+	balanced := r.balancer != nil && r.uri == ""
+
 	if requestPath, err = r.PrepareRequestURI(); err != nil {
 		return r, err
 	}
 	Logger.Debug("Request URI to %s", requestPath)
 
-	var body io.Reader
-	if r.provider != nil {
+	if !isToken(r.method) {
+		return r, fmt.Errorf("%w: %q", ErrInvalidMethod, r.method)
+	}
 
-		body, err = r.provider.Body()
-		if err != nil {
-			return r, err
-		}
+	// The body type goes with this request only, unless set explicitly for it
+	if scoped == nil {
+		scoped = http.Header{}
+	}
+	if provider != nil && scoped.Get(contentType) == "" {
+		scoped.Set(contentType, provider.ContentType())
 	}
 
-	// Prepares request struct with all fields needed
-	var request *http.Request
+	// Responses are decoded as set by Accept, or for this request only as its body when there's none
+	decoder := r.response
+	if scopedResponse != nil {
+		decoder = scopedResponse
+	} else if decoder == nil && provider != nil {
+		decoder = responseFor(provider.ContentType())
+	}
+	if decoder != nil && decoder != r.response && scoped.Get(acceptHeader) == "" {
+		scoped.Set(acceptHeader, decoder.Accept())
+	}
 
-	if request, err = http.NewRequestWithContext(r.ctx, r.method, requestPath, body); err != nil {
-		return r, err
+	// Starts a new redirect chain, carried by the request context
+	chain := &redirectChain{}
+	ctx := withRedirectChain(requestCtx, chain)
+	if len(scoped) > 0 {
+		ctx = withRequestHeaders(ctx, scoped)
 	}
 
-	// Proceed to clone headers pre populated to the request class
-	request.Header = r.header.Clone()
+	// We buffer the payload, so it can be signed and sent again on failover
+	var payload []byte
+	if provider != nil {
+
+		var body io.Reader
+		if body, err = provider.Body(); err != nil {
+			return r, err
+		}
+		if body != nil {
+			if payload, err = ioutil.ReadAll(body); err != nil {
+				return r, err
+			}
+		}
+	}
+
+	// Fire up the request against the server, with r or a snapshot of it
+	send := func(client *Requist, ctx context.Context) (*http.Response, error) {
+		switch {
+		case balanced:
+			return client.balance(ctx, requestPath, payload)
+		case client.hedging != nil && client.method == http.MethodGet:
+			return client.hedge(ctx, requestPath, payload)
+		}
+		return client.roundTrip(ctx, requestPath, payload)
+	}
+	fetch := func(ctx context.Context) (*http.Response, error) {
+		return send(r, ctx)
+	}
+	if r.coalescer != nil && r.signer == nil && r.method == http.MethodGet && payload == nil {
+		fetch = func(ctx context.Context) (*http.Response, error) {
+			return r.coalesce(ctx, requestPath, send)
+		}
+	}
 
-	// Fire up the request against the server
 	var response *http.Response
-	if response, err = r.client.Do(request); err != nil {
-		return r, err
+	r.cacheStatus = CacheMiss
+	if r.cache != nil && r.method == http.MethodGet && payload == nil {
+		response, r.cacheStatus, err = r.cached(ctx, requestPath, fetch)
+	} else {
+		response, err = fetch(ctx)
+	}
+	r.redirects = chain.list()
+	if err != nil {
+		return r, abortError(ctx, requestPath, err)
+	}
+
+	// Successful unsafe methods invalidate what we stored for that URI
+	if r.cache != nil && !safeMethods[r.method] && response.StatusCode < http.StatusBadRequest {
+		r.cache.invalidate(requestPath)
 	}
 
 	// Defer close response body
 	defer response.Body.Close()
-	defer r.CleanQueryParams()
 
 	// backup response StatusCode into Requist.statuscode
 	r.statuscode = response.StatusCode
+	r.responseHeader = response.Header
 	Logger.Debug("Response StatusCode %d", r.statuscode)
 
-	// Decode from r.response Accept() type
-	if (success != nil || failure != nil) && r.statuscode != 204 {
+	// backup budget advertised by the server into Requist.ratelimit
+	r.ratelimit = ParseRateLimit(response.Header, time.Now())
+
+	// backup validators, so the resource can be updated with preconditions
+	r.etag = response.Header.Get("ETag")
+	r.lastModified, _ = http.ParseTime(response.Header.Get("Last-Modified"))
+
+	// Decode from decoder Accept() type, HEAD and some status codes don't carry a body
+	if (success != nil || failure != nil) && hasBody(r.method, r.statuscode) {
 		if 200 <= r.statuscode && r.statuscode <= 299 {
 			if success != nil {
 
-				if r.response != nil {
-					Logger.Debug("Going to decode Response Body (%T) into success (%T)", r.response, success)
+				if decoder != nil {
+					Logger.Debug("Going to decode Response Body (%T) into success (%T)", decoder, success)
 
-					if err := r.response.Decode(response.Body, success); err != nil {
+					if err := decoder.Decode(response.Body, success); err != nil {
 						return r, err
 					}
 				}
@@ -199,22 +439,123 @@ func (r *Requist) Request(success, failure interface{}) (*Requist, error) {
 		} else {
 			if failure != nil {
 
-				if r.response != nil {
-					Logger.Debug("Going to decode Response Body (%T) into failure (%T)", r.response, failure)
+				if decoder != nil {
+					Logger.Debug("Going to decode Response Body (%T) into failure (%T)", decoder, failure)
 
-					if err := r.response.Decode(response.Body, failure); err != nil {
+					if err := decoder.Decode(response.Body, failure); err != nil {
 						return r, err
 					}
 				}
 			}
 		}
 	}
+
+	if r.statuscode == http.StatusPreconditionFailed && hasPreconditions(scoped) {
+		return r, &PreconditionFailedError{URI: requestPath, ETag: r.etag}
+	}
 	return r, err
 }
 
+// snapshot returns a copy of r to send requests in background, so r can keep being used and set meanwhile
+func (r *Requist) snapshot() *Requist {
+
+	clone := *r
+	header := r.header.Clone()
+	clone.header = &header
+	client := *r.client
+	clone.client = &client
+	clone.hostLimiters = map[string]*Limiter{}
+	for host, limiter := range r.hostLimiters {
+		clone.hostLimiters[host] = limiter
+	}
+	return &clone
+}
+
+// roundTrip builds the request to uri with payload and sends it
+func (r *Requist) roundTrip(ctx context.Context, uri string, payload []byte) (*http.Response, error) {
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	// Prepares request struct with all fields needed
+	request, err := http.NewRequestWithContext(ctx, r.method, uri, body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Proceed to clone headers pre populated to the request class, and those added for this request only
+	request.Header = r.header.Clone()
+	for key, values := range requestHeaders(ctx) {
+		request.Header[key] = values
+	}
+
+	// Sign the request once headers and body are in place
+	if r.signer != nil {
+		Logger.Debug("Signing Request with (%T)", r.signer)
+
+		if err = r.signer.Sign(request, payload); err != nil {
+			return nil, err
+		}
+	}
+
+	// Pace requests to hosts running out of budget
+	if r.throttler != nil {
+		if err = r.throttler.wait(request.Context(), request.URL.Host); err != nil {
+			return nil, err
+		}
+	}
+
+	// Wait for our turn on client and host limiters
+	release, err := r.limit(request.Context(), request.URL.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stop here if the host circuit is open
+	var token breakerToken
+	if r.breaker != nil {
+		if token, err = r.breaker.allow(request.URL.Host); err != nil {
+			release()
+			return nil, err
+		}
+	}
+
+	started := time.Now()
+	response, err := r.client.Do(request)
+
+	if r.breaker != nil {
+		if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+			// Canceled by us (ie: a hedged attempt that lost), it says nothing about the host
+			r.breaker.abandon(request.URL.Host, token)
+		} else {
+			r.breaker.record(request.URL.Host, token, response, err)
+		}
+	}
+	if err != nil {
+		release()
+
+		// The client gave up on this attempt while the request context was still alive
+		if ctx.Err() == nil && r.client.Timeout > 0 && time.Since(started) >= r.client.Timeout && isTimeout(err) {
+			err = &RequestError{URI: uri, Reason: ErrTimeout, Err: err}
+		}
+		return nil, err
+	}
+
+	if r.throttler != nil {
+		r.throttler.observe(request.URL.Host, response.Header)
+	}
+
+	// Limiters slots are kept until the response body is closed
+	response.Body = &releaser{ReadCloser: response.Body, release: release}
+	return response, nil
+}
+
 //#$$=== Provider Body functions, used to set type of payload send on request
 
-// BodyProvider sets the Request's body provider from original BodyProvider interface{}
+// BodyProvider sets the next Request's body provider from original BodyProvider interface{}, it's cleared once sent.
+// Without Accept, the response to that request is expected and decoded as the same type
 func (r *Requist) BodyProvider(body BodyProvider) *Requist {
 
 	Logger.Debug("Setting BodyProvider (%T)", body)
@@ -223,11 +564,8 @@ func (r *Requist) BodyProvider(body BodyProvider) *Requist {
 		return r
 	}
 
-	ct := body.ContentType()
-	if ct != "" {
+	if body.ContentType() != "" {
 		r.provider = body
-		r.SetHeader(contentType, ct)
-		r.Accept(ct)
 	}
 
 	return r
@@ -288,16 +626,25 @@ func (r *Requist) Accept(accept string) {
 
 	Logger.Debug("Setting Accept (%s)", accept)
 
+	if decoder := responseFor(accept); decoder != nil {
+		r.BodyResponse(decoder)
+	} else {
+		r.response = nil
+	}
+}
+
+// responseFor returns the BodyResponse decoding accept mimeType, nil if unknown
+func responseFor(accept string) BodyResponse {
+
 	switch accept {
 	case FormContentType:
-		r.BodyResponse(formResponse{})
+		return formResponse{}
 	case JSONContentType:
-		r.BodyResponse(jsonResponse{})
+		return jsonResponse{}
 	case TextContentType:
-		r.BodyResponse(textResponse{})
-	default:
-		r.response = nil
+		return textResponse{}
 	}
+	return nil
 }
 
 //#$$=== QueryParams manipulation functions
@@ -314,36 +661,79 @@ func (r *Requist) PrepareRequestURI() (uri string, err error) {
 		if err != nil {
 			return "", err
 		}
-		reqURL.Path = r.path
-		reqURL.RawQuery = r.queries.Encode()
+		// Without path we target the base URL itself, prefix included
+		if r.path != "" {
+			if reqURL.Path, err = url.PathUnescape(r.path); err != nil {
+				return "", err
+			}
+			reqURL.RawPath = r.path
+		}
+		reqURL.RawQuery = r.query().Encode()
 		uri = reqURL.String()
 	}
 	return uri, err
 }
 
+// query returns default QueryParams overridden by those set for next request
+func (r *Requist) query() url.Values {
+
+	query := url.Values{}
+	for key, values := range r.defaultQueries {
+		query[key] = append([]string(nil), values...)
+	}
+	if r.queries != nil {
+		for key, values := range *r.queries {
+			query[key] = values
+		}
+	}
+	return query
+}
+
 //#$$=== Header manipulation functions
 
-// AddHeader adds the key, value pair in Headers, appending values for existing keys
+// AddHeader adds the key, value pair in Headers sent with every request, appending values for existing keys
 // to the key's values. Header keys are canonicalized.
 func (r *Requist) AddHeader(key, value string) {
 
 	r.header.Add(key, value)
 }
 
-// SetHeader sets the key, value pair in Headers, replacing existing values
+// SetHeader sets the key, value pair in Headers sent with every request, replacing existing values
 // associated with key. Header keys are canonicalized.
 func (r *Requist) SetHeader(key, value string) {
 
 	r.header.Set(key, value)
 }
 
-// DelHeader remove the key, value pair in Headers
+// DelHeader remove the key, value pair in Headers sent with every request
 func (r *Requist) DelHeader(key string) {
 
 	r.header.Del(key)
 }
 
-// AddQueryParam adds the key, value tuples in QueryParams, appending values for existing keys
+// AddRequestHeader adds the key, value pair to next request only, appending values for existing keys
+func (r *Requist) AddRequestHeader(key, value string) *Requist {
+
+	if r.scoped == nil {
+		r.scoped = http.Header{}
+	}
+	r.scoped.Add(key, value)
+
+	return r
+}
+
+// SetRequestHeader sets the key, value pair on next request only, replacing the values sent with every request
+func (r *Requist) SetRequestHeader(key, value string) *Requist {
+
+	if r.scoped == nil {
+		r.scoped = http.Header{}
+	}
+	r.scoped.Set(key, value)
+
+	return r
+}
+
+// AddQueryParam adds the key, value tuples in next request QueryParams, appending values for existing keys
 func (r *Requist) AddQueryParam(key, value string) {
 
 	if r.queries != nil {
@@ -351,7 +741,7 @@ func (r *Requist) AddQueryParam(key, value string) {
 	}
 }
 
-// SetQueryParam set the key, value tuples in params to
+// SetQueryParam set the key, value tuples in next request QueryParams
 func (r *Requist) SetQueryParam(key, value string) {
 
 	if r.queries != nil {
@@ -359,7 +749,7 @@ func (r *Requist) SetQueryParam(key, value string) {
 	}
 }
 
-// DelQueryParam remove the key from QueryParams
+// DelQueryParam remove the key from next request QueryParams
 func (r *Requist) DelQueryParam(key string) {
 
 	if r.queries != nil {
@@ -367,12 +757,31 @@ func (r *Requist) DelQueryParam(key string) {
 	}
 }
 
-// CleanQueryParams remove all keys from QueryParams
+// CleanQueryParams remove all keys from next request QueryParams, done after every request
 func (r *Requist) CleanQueryParams() {
 
 	r.queries = &url.Values{}
 }
 
+// AddDefaultQueryParam adds the key, value tuples in QueryParams sent with every request
+func (r *Requist) AddDefaultQueryParam(key, value string) {
+
+	r.defaultQueries.Add(key, value)
+}
+
+// SetDefaultQueryParam sets the key, value tuples in QueryParams sent with every request,
+// next request QueryParams with the same key replace them
+func (r *Requist) SetDefaultQueryParam(key, value string) {
+
+	r.defaultQueries.Set(key, value)
+}
+
+// DelDefaultQueryParam remove the key from QueryParams sent with every request
+func (r *Requist) DelDefaultQueryParam(key string) {
+
+	r.defaultQueries.Del(key)
+}
+
 // SetBasicAuth sets the Authorization header to use HTTP Basic Authentication
 func (r *Requist) SetBasicAuth(username, password string) *Requist {
 
@@ -384,6 +793,16 @@ func (r *Requist) SetBasicAuth(username, password string) *Requist {
 	return r
 }
 
+// SetSigner sets the Signer used to sign every request before being sent
+func (r *Requist) SetSigner(signer Signer) *Requist {
+
+	Logger.Debug("Setting Signer (%T)", signer)
+
+	r.signer = signer
+
+	return r
+}
+
 //=== Utilities functions, used to return some values from Requist class
 
 // StatusCode return the HTTP StatusCode from last request
@@ -400,22 +819,66 @@ func (r *Requist) GetBasicAuth() string {
 
 //=== Utilities functions, to set up URL base, URL path, HTTP method to use...
 
-// Base sets base url to use for a client
+// Base sets base url to use for a client, unix:// and http+unix:// bases dial the socket given
 func (r *Requist) Base(base string) *Requist {
 
+	if socket := ParseUnixSocket(base); socket != "" {
+		r.SetClientUnixSocket(socket)
+		r.url = unixBaseURL
+
+		// http+unix bases may carry a path prefix after the socket
+		if strings.HasPrefix(base, unixHTTPScheme) {
+			rest := strings.TrimPrefix(base, unixHTTPScheme)
+			if i := strings.IndexByte(rest, '/'); i >= 0 {
+				r.url = ParseBaseURL(unixBaseURL + rest[i:])
+			}
+		}
+
+		return r
+	}
+
+	if r.socket != "" {
+		r.SetClientUnixSocket("")
+	}
 	r.url = ParseBaseURL(base)
 
 	return r
 }
 
-// Path sets request path to use in next request
+// Path sets request path to use in next request, a query string in path is added to QueryParams
 func (r *Requist) Path(path string) *Requist {
 
 	r.path = ParsePathURL(r.url, path)
 
+	if i := strings.IndexByte(path, '?'); i >= 0 && r.path != "" {
+		query := path[i+1:]
+		if j := strings.IndexByte(query, '#'); j >= 0 {
+			query = query[:j]
+		}
+		if values, err := url.ParseQuery(query); err == nil {
+			for key, list := range values {
+				for _, value := range list {
+					r.queries.Add(key, value)
+				}
+			}
+		}
+	}
+
 	return r
 }
 
+// PathTemplate expands template (RFC 6570, ie: /users/{id}/repos{?type,sort}) with params, a map or a struct
+// with url tags, and sets it as request path to use in next request
+func (r *Requist) PathTemplate(template string, params interface{}) (*Requist, error) {
+
+	path, err := ExpandTemplate(template, params)
+	if err != nil {
+		return r, err
+	}
+
+	return r.Path(path), nil
+}
+
 // URI sets request uri to use in next request
 func (r *Requist) URI(uri string) *Requist {
 
@@ -424,34 +887,14 @@ func (r *Requist) URI(uri string) *Requist {
 	return r
 }
 
-// Method set HTTP Method to execute
+// Method set HTTP Method to execute, GET if empty. Standard methods are case insensitive, extension ones
+// (ie: WebDAV PROPFIND or MKCOL, PURGE) are sent as is and must be RFC 7230 tokens, or Request fails
 func (r *Requist) Method(method string) *Requist {
 
-	switch strings.ToUpper(method) {
-	case http.MethodGet:
-		r.method = http.MethodGet
-
-	case http.MethodPut:
-		r.method = http.MethodPut
-
-	case http.MethodPost:
-		r.method = http.MethodPost
-
-	case http.MethodPatch:
-		r.method = http.MethodPatch
-
-	case http.MethodDelete:
-		r.method = http.MethodDelete
-
-	case http.MethodOptions:
-		r.method = http.MethodOptions
-
-	case http.MethodConnect:
-		r.method = http.MethodConnect
-
-	default:
-		r.method = http.MethodGet
+	if method == "" {
+		method = http.MethodGet
 	}
+	r.method = normalizeMethod(method)
 
 	return r
 }
@@ -464,6 +907,12 @@ func (r *Requist) Get(path string, success, failure interface{}) (*Requist, erro
 	return r.Method(http.MethodGet).Path(path).Request(success, failure)
 }
 
+// Head implement HEAD HTTP Method, only headers and StatusCode are received
+func (r *Requist) Head(path string, success, failure interface{}) (*Requist, error) {
+
+	return r.Method(http.MethodHead).Path(path).Request(success, failure)
+}
+
 // Put implement PUT HTTP Method
 func (r *Requist) Put(path string, success, failure interface{}) (*Requist, error) {
 
@@ -499,3 +948,9 @@ func (r *Requist) Connect(path string, success, failure interface{}) (*Requist,
 
 	return r.Method(http.MethodConnect).Path(path).Request(success, failure)
 }
+
+// Trace implement TRACE HTTP Method
+func (r *Requist) Trace(path string, success, failure interface{}) (*Requist, error) {
+
+	return r.Method(http.MethodTrace).Path(path).Request(success, failure)
+}
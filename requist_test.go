@@ -3,9 +3,11 @@ package requist
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"github.com/hashicorp/go-cleanhttp"
 	"github.com/stretchr/testify/assert"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -115,6 +117,48 @@ func TestRequist_SetClientTransport(t *testing.T) {
 	assert.NotNil(t, emptyClient.client.Transport)
 }
 
+// roundTripperFunc is a custom RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+func TestRequist_SetClientRoundTripper(t *testing.T) {
+
+	// We create a Mock Server
+	server := MockHTTPServer()
+	defer server.Close()
+
+	// We create our requist Client
+	emptyClient := New(server.URL)
+	transport := emptyClient.client.Transport
+
+	var requests int
+	emptyClient.SetClientRoundTripper(roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		requests++
+		return transport.RoundTrip(request)
+	}))
+
+	// Transport settings are kept while the RoundTripper is set
+	emptyClient.SetClientTLSServerName("apitest.org")
+
+	_, err := emptyClient.Get("/user", nil, nil)
+
+	// our data is correct?
+	assert.Nil(t, err)
+	assert.Equal(t, 1, requests)
+
+	emptyClient.SetClientRoundTripper(nil)
+	_, err = emptyClient.Get("/user", nil, nil)
+
+	// our data is correct?
+	assert.Nil(t, err)
+	assert.Equal(t, 1, requests)
+	assert.Equal(t, transport, emptyClient.client.Transport)
+	assert.Equal(t, "apitest.org", emptyClient.transport().TLSClientConfig.ServerName)
+}
+
 func TestRequist_SetClientTimeout(t *testing.T) {
 
 	// We define some variables
@@ -166,7 +210,8 @@ func TestRequist_SetClientContext(t *testing.T) {
 	t.Run("Must be success with Cancel context", func(t *testing.T) {
 		// We define some variables
 		baseURL := "http://live.apitest.org"
-		ctx, _ := context.WithCancel(context.Background())
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
 
 		// We create our requist Client
 		emptyClient := New(baseURL)
@@ -845,6 +890,159 @@ func TestRequist_CleanQueryParams(t *testing.T) {
 	})
 }
 
+func TestRequist_DefaultQueryParams(t *testing.T) {
+
+	// We create our requist Client
+	emptyClient := New("http://live.apitest.org")
+
+	t.Run("merge defaults with next request params", func(t *testing.T) {
+
+		emptyClient.SetDefaultQueryParam("api_key", "secret")
+		emptyClient.AddDefaultQueryParam("lang", "en")
+		emptyClient.SetQueryParam("lang", "es")
+		emptyClient.AddQueryParam("page", "2")
+
+		result, err := emptyClient.PrepareRequestURI()
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, "http://live.apitest.org?api_key=secret&lang=es&page=2", result)
+		assert.Equal(t, "en", emptyClient.defaultQueries.Get("lang"))
+	})
+
+	t.Run("delete a default", func(t *testing.T) {
+
+		emptyClient.CleanQueryParams()
+		emptyClient.DelDefaultQueryParam("lang")
+
+		result, err := emptyClient.PrepareRequestURI()
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, "http://live.apitest.org?api_key=secret", result)
+	})
+}
+
+func TestRequist_Lifecycle(t *testing.T) {
+
+	var received []*http.Request
+	var bodies []string
+
+	// We create a Mock Server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received = append(received, r)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	// We create our requist Client
+	emptyClient := New(server.URL)
+	emptyClient.SetHeader("X-Client", "requist")
+	emptyClient.SetHeader("X-Trace", "default")
+	emptyClient.SetDefaultQueryParam("api_key", "secret")
+
+	// first request carries a body, per-request header and param
+	emptyClient.SetQueryParam("page", "2")
+	_, err := emptyClient.BodyAsJSON(&UserInfo{Name: "Jonah Doe", Age: 47}).SetRequestHeader("X-Trace", "once").Post("/users", nil, nil)
+	assert.Nil(t, err)
+
+	// second request must only carry client level defaults
+	_, err = emptyClient.Get("/users", nil, nil)
+	assert.Nil(t, err)
+
+	// our data is correct?
+	assert.Len(t, received, 2)
+
+	t.Run("send per-request values once", func(t *testing.T) {
+		assert.Equal(t, http.MethodPost, received[0].Method)
+		assert.Equal(t, `{"name":"Jonah Doe","age":47}`, strings.TrimSpace(bodies[0]))
+		assert.Equal(t, JSONContentType, received[0].Header.Get("Content-Type"))
+		assert.Equal(t, "once", received[0].Header.Get("X-Trace"))
+		assert.Equal(t, "api_key=secret&page=2", received[0].URL.RawQuery)
+	})
+
+	t.Run("reset per-request values after sending", func(t *testing.T) {
+		assert.Equal(t, http.MethodGet, received[1].Method)
+		assert.Equal(t, "", bodies[1])
+		assert.Equal(t, "", received[1].Header.Get("Content-Type"))
+		assert.Equal(t, "default", received[1].Header.Get("X-Trace"))
+		assert.Equal(t, "api_key=secret", received[1].URL.RawQuery)
+		assert.Nil(t, emptyClient.provider)
+	})
+
+	t.Run("keep client level defaults", func(t *testing.T) {
+		for _, request := range received {
+			assert.Equal(t, "requist", request.Header.Get("X-Client"))
+		}
+	})
+
+	t.Run("reset per-request values on errors", func(t *testing.T) {
+
+		emptyClient.SetQueryParam("page", "3")
+		_, err := emptyClient.BodyAsText("lost").SetRequestHeader("X-Trace", "lost").URI("http://[::1]:namedport").Request(nil, nil)
+
+		// our data is correct?
+		assert.NotNil(t, err)
+		assert.Nil(t, emptyClient.provider)
+		assert.Nil(t, emptyClient.scoped)
+		assert.Empty(t, *emptyClient.queries)
+	})
+
+	var accepted []string
+
+	// We create a Mock Server answering JSON
+	jsonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accepted = append(accepted, r.Header.Get("Accept"))
+		w.Header().Set("Content-Type", JSONContentType)
+		_, _ = w.Write([]byte(`{"name": "Jonah Doe", "age": 50}`))
+	}))
+	defer jsonServer.Close()
+
+	t.Run("keep Accept and decoder over body types", func(t *testing.T) {
+
+		accepted = nil
+
+		// We create our requist Client
+		jsonClient := New(jsonServer.URL)
+		jsonClient.Accept(JSONContentType)
+
+		_, err := jsonClient.BodyAsText("x").Post("/users", nil, nil)
+		assert.Nil(t, err)
+
+		user := &UserInfo{}
+		_, err = jsonClient.Get("/users/1", user, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, []string{JSONContentType, JSONContentType}, accepted)
+		assert.Equal(t, "Jonah Doe", user.Name)
+	})
+
+	t.Run("decode as the body type for that request only", func(t *testing.T) {
+
+		accepted = nil
+
+		// We create our requist Client
+		jsonClient := New(jsonServer.URL)
+
+		created := &UserInfo{}
+		_, err := jsonClient.BodyAsJSON(&UserInfo{Name: "Jonah Doe"}).Post("/users", created, nil)
+		assert.Nil(t, err)
+
+		user := &UserInfo{}
+		_, err = jsonClient.Get("/users/1", user, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, []string{JSONContentType, ""}, accepted)
+		assert.Equal(t, 50, created.Age)
+		assert.Equal(t, &UserInfo{}, user)
+		assert.Nil(t, jsonClient.response)
+	})
+}
+
 func TestRequist_SetBasicAuth(t *testing.T) {
 
 	// We define some variables
@@ -1134,6 +1332,39 @@ func TestRequist_Path(t *testing.T) {
 	})
 }
 
+func TestRequist_BasePrefix(t *testing.T) {
+
+	var received string
+
+	// We create a Mock Server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.URL.RequestURI()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	// We create our requist Client
+	emptyClient := New(server.URL + "/api/v2")
+
+	t.Run("prefix request paths", func(t *testing.T) {
+
+		_, err := emptyClient.Get("/users", nil, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, "/api/v2/users", received)
+	})
+
+	t.Run("target base itself with empty path", func(t *testing.T) {
+
+		_, err := emptyClient.Get("", nil, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, "/api/v2", received)
+	})
+}
+
 func TestRequist_Method(t *testing.T) {
 
 	// We define some variables
@@ -1157,10 +1388,10 @@ func TestRequist_Method(t *testing.T) {
 		assert.EqualValues(t, expected, emptyClient.method)
 	})
 
-	t.Run("get method defined to GET if method passed is invalid", func(t *testing.T) {
+	t.Run("get method kept as is if method passed is an extension one", func(t *testing.T) {
 
-		emptyClient.Method("INVALID")
-		expected := "GET"
+		emptyClient.Method("PROPFIND")
+		expected := "PROPFIND"
 
 		// was modified out Client?
 		assert.NotNil(t, emptyClient)
@@ -1183,8 +1414,8 @@ func TestRequist_Method(t *testing.T) {
 
 	t.Run("get method defined to DELETE if method passed is delete (case insensitive)", func(t *testing.T) {
 
-		emptyClient.Method("INVALID")
-		expected := "GET"
+		emptyClient.Method("delete")
+		expected := "DELETE"
 
 		// was modified out Client?
 		assert.NotNil(t, emptyClient)
@@ -1194,6 +1425,88 @@ func TestRequist_Method(t *testing.T) {
 	})
 }
 
+func TestRequist_CustomMethods(t *testing.T) {
+
+	var received []string
+
+	// We create a Mock Server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Method)
+		switch r.Method {
+		case http.MethodHead:
+			w.Header().Set("Content-Type", JSONContentType)
+			w.Header().Set("Content-Length", "27")
+			w.WriteHeader(http.StatusOK)
+		case "PROPFIND":
+			w.Header().Set("Content-Type", JSONContentType)
+			w.WriteHeader(http.StatusMultiStatus)
+			_, _ = w.Write([]byte(`{"result": "Listed"}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("send HEAD without decoding a body", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.Accept(JSONContentType)
+
+		success := &GenericResponse{}
+		_, err := emptyClient.Head("/resource", success, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, emptyClient.StatusCode())
+		assert.Equal(t, "", success.Result)
+	})
+
+	t.Run("send TRACE", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+
+		_, err := emptyClient.Trace("/resource", nil, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, http.MethodTrace, received[len(received)-1])
+	})
+
+	t.Run("send extension methods as is", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.Accept(JSONContentType)
+
+		success := &GenericResponse{}
+		for _, method := range []string{"PROPFIND", "MKCOL", "PURGE"} {
+			_, err := emptyClient.Method(method).Path("/resource").Request(success, nil)
+			assert.Nil(t, err)
+			assert.Equal(t, method, received[len(received)-1])
+		}
+
+		// our data is correct?
+		assert.Equal(t, "Listed", success.Result)
+	})
+
+	t.Run("fail with methods not being tokens", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		count := len(received)
+
+		for _, method := range []string{"BAD METHOD", "GET\r\n", "(GET)"} {
+			_, err := emptyClient.Method(method).Path("/resource").Request(nil, nil)
+			assert.True(t, errors.Is(err, ErrInvalidMethod), method)
+		}
+
+		// our data is correct?
+		assert.Len(t, received, count)
+	})
+}
+
 //===
 
 func TestRequist_New(t *testing.T) {
@@ -0,0 +1,213 @@
+package requisttest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+)
+
+//=== Cassettes, recorded interactions stored as JSON
+
+// cassetteVersion is the version of the cassette format written
+const cassetteVersion = 1
+
+// Redacted replaces secrets in cassettes
+const Redacted = "REDACTED"
+
+// Cassette holds the interactions recorded
+type Cassette struct {
+	Version      int            `json:"version"`
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is a request and the response received
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a request as stored in a cassette
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitempty"`
+}
+
+// RecordedResponse is a response as stored in a cassette
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       Body        `json:"body,omitempty"`
+}
+
+// Body is stored as text, or base64 encoded when it isn't valid UTF-8
+type Body []byte
+
+// MarshalJSON implements json.Marshaler
+func (b Body) MarshalJSON() ([]byte, error) {
+
+	if utf8.Valid(b) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(map[string]string{"base64": base64.StdEncoding.EncodeToString(b)})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (b *Body) UnmarshalJSON(data []byte) error {
+
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*b = Body(text)
+		return nil
+	}
+
+	var encoded map[string]string
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded["base64"])
+	*b = decoded
+	return err
+}
+
+// loadCassette reads the cassette at filename, exists is false if there isn't one
+func loadCassette(filename string) (cassette *Cassette, exists bool, err error) {
+
+	content, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return &Cassette{Version: cassetteVersion}, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	cassette = &Cassette{}
+	if err = json.Unmarshal(content, cassette); err != nil {
+		return nil, true, err
+	}
+	return cassette, true, nil
+}
+
+// save writes the cassette to filename, creating its directory if needed
+func (c *Cassette) save(filename string) error {
+
+	content, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(filename)
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	// Written aside and renamed, a failure never leaves a truncated cassette
+	file, err := ioutil.TempFile(dir, filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(append(content, '\n')); err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return err
+	}
+	if err = file.Close(); err != nil {
+		_ = os.Remove(file.Name())
+		return err
+	}
+	return os.Rename(file.Name(), filename)
+}
+
+// redact replaces header values and query params listed as secrets
+func redact(header http.Header, rawURL string, headers, params []string) (http.Header, string) {
+
+	header = header.Clone()
+	for _, key := range headers {
+		if values := header.Values(key); len(values) > 0 {
+			redacted := make([]string, len(values))
+			for i := range redacted {
+				redacted[i] = Redacted
+			}
+			header[http.CanonicalHeaderKey(key)] = redacted
+		}
+	}
+
+	if len(params) == 0 {
+		return header, rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return header, rawURL
+	}
+	query := u.Query()
+	changed := false
+	for _, key := range params {
+		if values, ok := query[key]; ok {
+			for i := range values {
+				values[i] = Redacted
+			}
+			changed = true
+		}
+	}
+	if changed {
+		u.RawQuery = query.Encode()
+	}
+	return header, u.String()
+}
+
+//=== Request matching
+
+// Matcher tells if a live request, redacted as it would be recorded, matches a recorded one
+type Matcher func(live, recorded *RecordedRequest) bool
+
+// MatchMethod matches requests with the same method
+func MatchMethod(live, recorded *RecordedRequest) bool {
+
+	return live.Method == recorded.Method
+}
+
+// MatchURL matches requests to the same URL, query params order aside
+func MatchURL(live, recorded *RecordedRequest) bool {
+
+	l, err := url.Parse(live.URL)
+	if err != nil {
+		return false
+	}
+	r, err := url.Parse(recorded.URL)
+	if err != nil {
+		return false
+	}
+	return l.Scheme == r.Scheme && l.Host == r.Host && l.EscapedPath() == r.EscapedPath() &&
+		l.Query().Encode() == r.Query().Encode()
+}
+
+// MatchBody matches requests with the same body
+func MatchBody(live, recorded *RecordedRequest) bool {
+
+	return string(live.Body) == string(recorded.Body)
+}
+
+// MatchHeader returns a Matcher of requests with the same values for header keys
+func MatchHeader(keys ...string) Matcher {
+
+	return func(live, recorded *RecordedRequest) bool {
+		for _, key := range keys {
+			l, r := live.Header.Values(key), recorded.Header.Values(key)
+			if len(l) != len(r) {
+				return false
+			}
+			for i := range l {
+				if l[i] != r[i] {
+					return false
+				}
+			}
+		}
+		return true
+	}
+}
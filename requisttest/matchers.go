@@ -0,0 +1,34 @@
+package requisttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+//=== Body matchers
+
+// BodyEquals matches bodies equal to expected
+func BodyEquals(expected string) func(body []byte) bool {
+
+	return func(body []byte) bool {
+		return bytes.Equal(body, []byte(expected))
+	}
+}
+
+// JSONEquals matches JSON bodies holding the same value as v once encoded, regardless of spacing and keys order
+func JSONEquals(v interface{}) func(body []byte) bool {
+
+	return func(body []byte) bool {
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return false
+		}
+
+		var expected, actual interface{}
+		if json.Unmarshal(encoded, &expected) != nil || json.Unmarshal(body, &actual) != nil {
+			return false
+		}
+		return reflect.DeepEqual(expected, actual)
+	}
+}
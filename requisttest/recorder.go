@@ -0,0 +1,237 @@
+package requisttest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/dotWicho/requist"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+//=== Record and replay of HTTP interactions
+
+// Mode defines how a Recorder handles requests
+type Mode int
+
+const (
+	// ModeReplay answers from the cassette only, failing requests not recorded
+	ModeReplay Mode = iota
+	// ModeRecord sends every request and records it, replacing the cassette
+	ModeRecord
+	// ModeRecordMissing answers from the cassette, sending and recording requests not found in it
+	ModeRecordMissing
+	// ModePassthrough sends every request, the cassette is neither read nor written
+	ModePassthrough
+)
+
+// ErrInteractionNotFound is returned in ModeReplay for requests not found in the cassette
+var ErrInteractionNotFound = errors.New("interaction not found in cassette")
+
+// defaultRedactedHeaders are the headers never stored as is
+var defaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// Recorder is an http.RoundTripper recording interactions into a cassette and replaying them
+type Recorder struct {
+	// Next sends the requests not replayed, http.DefaultTransport if nil
+	Next http.RoundTripper
+	// Match are the Matchers a recorded request must pass to be replayed, method and URL by default
+	Match []Matcher
+	// RedactHeaders and RedactQuery are request and response headers and query params stored as REDACTED,
+	// Authorization, Proxy-Authorization, Cookie and Set-Cookie headers by default
+	RedactHeaders []string
+	RedactQuery   []string
+	// Filter, if set, changes interactions before being stored, ie: to remove secrets from bodies
+	Filter func(interaction *Interaction)
+
+	mode     Mode
+	filename string
+
+	mutex    sync.Mutex
+	cassette *Cassette
+	replayed map[*Interaction]bool
+	changed  bool
+}
+
+// NewRecorder returns a Recorder in mode using the cassette stored at filename, which must exist in ModeReplay
+func NewRecorder(filename string, mode Mode) (*Recorder, error) {
+
+	rec := &Recorder{
+		Match:         []Matcher{MatchMethod, MatchURL},
+		RedactHeaders: defaultRedactedHeaders,
+		mode:          mode,
+		filename:      filename,
+		cassette:      &Cassette{Version: cassetteVersion},
+		replayed:      map[*Interaction]bool{},
+		changed:       mode == ModeRecord,
+	}
+
+	switch mode {
+	case ModeReplay, ModeRecordMissing:
+		cassette, exists, err := loadCassette(filename)
+		if err != nil {
+			return nil, fmt.Errorf("loading cassette %s: %w", filename, err)
+		}
+		if !exists && mode == ModeReplay {
+			return nil, fmt.Errorf("cassette %s not found, record it first", filename)
+		}
+		rec.cassette = cassette
+	case ModeRecord, ModePassthrough:
+	default:
+		return nil, fmt.Errorf("unknown recorder mode %d", mode)
+	}
+	return rec, nil
+}
+
+// Record sends client requests through a new Recorder, saving the cassette when the test ends
+func Record(t TestingT, client *requist.Requist, filename string, mode Mode) (*Recorder, error) {
+
+	rec, err := NewRecorder(filename, mode)
+	if err != nil {
+		return nil, err
+	}
+	rec.Next = client.ClientTransport()
+	client.SetClientRoundTripper(rec)
+
+	t.Cleanup(func() {
+		if err := rec.Stop(); err != nil {
+			t.Errorf("requisttest: saving cassette %s: %s", filename, err)
+		}
+	})
+	return rec, nil
+}
+
+// Stop writes the cassette if anything was recorded, always in ModeRecord
+func (rec *Recorder) Stop() error {
+
+	rec.mutex.Lock()
+	defer rec.mutex.Unlock()
+
+	if !rec.changed {
+		return nil
+	}
+	rec.changed = false
+	return rec.cassette.save(rec.filename)
+}
+
+// RoundTrip implements http.RoundTripper replaying or recording request following the Recorder mode
+func (rec *Recorder) RoundTrip(request *http.Request) (*http.Response, error) {
+
+	if rec.mode == ModePassthrough {
+		return rec.next().RoundTrip(request)
+	}
+
+	var body []byte
+	if request.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(request.Body); err != nil {
+			return nil, err
+		}
+		_ = request.Body.Close()
+		request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	live := rec.record(request, body)
+
+	if rec.mode != ModeRecord {
+		if interaction := rec.lookup(live); interaction != nil {
+			return replay(request, interaction), nil
+		}
+		if rec.mode == ModeReplay {
+			return nil, fmt.Errorf("%w %s: %s %s", ErrInteractionNotFound, rec.filename, live.Method, live.URL)
+		}
+	}
+
+	response, err := rec.next().RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+	content, err := ioutil.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = ioutil.NopCloser(bytes.NewReader(content))
+
+	header, _ := redact(response.Header, "", rec.RedactHeaders, nil)
+	interaction := &Interaction{
+		Request:  *live,
+		Response: RecordedResponse{StatusCode: response.StatusCode, Header: header, Body: content},
+	}
+	if rec.Filter != nil {
+		rec.Filter(interaction)
+	}
+
+	rec.mutex.Lock()
+	rec.cassette.Interactions = append(rec.cassette.Interactions, interaction)
+	rec.replayed[interaction] = true
+	rec.changed = true
+	rec.mutex.Unlock()
+
+	return response, nil
+}
+
+// next returns the RoundTripper sending requests not replayed
+func (rec *Recorder) next() http.RoundTripper {
+
+	if rec.Next != nil {
+		return rec.Next
+	}
+	return http.DefaultTransport
+}
+
+// record returns request with body as it would be stored, secrets redacted
+func (rec *Recorder) record(request *http.Request, body []byte) *RecordedRequest {
+
+	header, uri := redact(request.Header, request.URL.String(), rec.RedactHeaders, rec.RedactQuery)
+	return &RecordedRequest{Method: request.Method, URL: uri, Header: header, Body: body}
+}
+
+// lookup returns the first interaction matching live not replayed yet, or the last one matching it
+func (rec *Recorder) lookup(live *RecordedRequest) *Interaction {
+
+	rec.mutex.Lock()
+	defer rec.mutex.Unlock()
+
+	var last *Interaction
+	for _, interaction := range rec.cassette.Interactions {
+		if !rec.matches(live, &interaction.Request) {
+			continue
+		}
+		if !rec.replayed[interaction] {
+			rec.replayed[interaction] = true
+			return interaction
+		}
+		last = interaction
+	}
+	return last
+}
+
+// matches tells if live passes every Matcher against recorded
+func (rec *Recorder) matches(live, recorded *RecordedRequest) bool {
+
+	for _, match := range rec.Match {
+		if !match(live, recorded) {
+			return false
+		}
+	}
+	return true
+}
+
+// replay builds the response to request stored in interaction
+func replay(request *http.Request, interaction *Interaction) *http.Response {
+
+	status := interaction.Response.StatusCode
+	body := []byte(interaction.Response.Body)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        interaction.Response.Header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       request,
+	}
+}
@@ -0,0 +1,194 @@
+package requisttest
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/dotWicho/requist"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// MockUsersServer serves users as JSON, counting requests
+func MockUsersServer(requests *int32) *httptest.Server {
+
+	return httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(requests, 1)
+			w.Header().Set("Content-Type", requist.JSONContentType)
+			w.Header().Set("Set-Cookie", "session=secret")
+			switch r.Method {
+			case http.MethodPost:
+				body, _ := ioutil.ReadAll(r.Body)
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write(body)
+			default:
+				_, _ = w.Write([]byte(`{"name": "Jonah Doe", "age": 50}`))
+			}
+		}),
+	)
+}
+
+func TestRecorder(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "requisttest")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	cassette := filepath.Join(dir, "fixtures", "users.json")
+
+	var requests int32
+
+	// We create a Mock Server
+	server := MockUsersServer(&requests)
+	defer server.Close()
+
+	t.Run("record interactions", func(t *testing.T) {
+
+		rec := &recorder{}
+
+		// We create our requist Client
+		client := requist.New(server.URL)
+		client.Accept(requist.JSONContentType)
+		client.SetHeader("Authorization", "Bearer secret")
+
+		vcr, err := Record(rec, client, cassette, ModeRecord)
+		assert.Nil(t, err)
+		vcr.RedactQuery = []string{"api_key"}
+
+		user := &UserInfo{}
+		client.SetQueryParam("api_key", "secret")
+		_, err = client.Get("/users/1", user, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, "Jonah Doe", user.Name)
+
+		_, err = client.BodyAsJSON(&UserInfo{Name: "Jason Borne", Age: 47}).Post("/users", user, nil)
+		assert.Nil(t, err)
+		rec.end()
+
+		// our data is correct?
+		assert.Empty(t, rec.failures)
+		assert.EqualValues(t, 2, atomic.LoadInt32(&requests))
+
+		content, err := ioutil.ReadFile(cassette)
+		assert.Nil(t, err)
+		assert.False(t, strings.Contains(string(content), "secret"))
+
+		stored := &Cassette{}
+		assert.Nil(t, json.Unmarshal(content, stored))
+		assert.Len(t, stored.Interactions, 2)
+		assert.Equal(t, server.URL+"/users/1?api_key=REDACTED", stored.Interactions[0].Request.URL)
+		assert.Equal(t, Redacted, stored.Interactions[0].Request.Header.Get("Authorization"))
+		assert.Equal(t, Redacted, stored.Interactions[0].Response.Header.Get("Set-Cookie"))
+		assert.Equal(t, `{"name":"Jason Borne","age":47}`, strings.TrimSpace(string(stored.Interactions[1].Request.Body)))
+	})
+
+	t.Run("replay interactions without network", func(t *testing.T) {
+
+		rec := &recorder{}
+		atomic.StoreInt32(&requests, 0)
+
+		// We create our requist Client
+		client := requist.New(server.URL)
+		client.Accept(requist.JSONContentType)
+
+		vcr, err := Record(rec, client, cassette, ModeReplay)
+		assert.Nil(t, err)
+		vcr.RedactQuery = []string{"api_key"}
+
+		user := &UserInfo{}
+		client.SetQueryParam("api_key", "another")
+		_, err = client.Get("/users/1", user, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, UserInfo{Name: "Jonah Doe", Age: 50}, *user)
+		assert.Equal(t, http.StatusOK, client.StatusCode())
+
+		_, err = client.Get("/users/2", user, nil)
+
+		// our data is correct?
+		assert.True(t, errors.Is(err, ErrInteractionNotFound))
+		assert.True(t, strings.Contains(err.Error(), "GET "+server.URL+"/users/2"))
+		assert.EqualValues(t, 0, atomic.LoadInt32(&requests))
+		rec.end()
+		assert.Empty(t, rec.failures)
+	})
+
+	t.Run("record missing interactions", func(t *testing.T) {
+
+		rec := &recorder{}
+		atomic.StoreInt32(&requests, 0)
+
+		// We create our requist Client
+		client := requist.New(server.URL)
+
+		vcr, err := Record(rec, client, cassette, ModeRecordMissing)
+		assert.Nil(t, err)
+		vcr.Match = append(vcr.Match, MatchBody)
+
+		for i := 0; i < 2; i++ {
+			_, err = client.BodyAsJSON(&UserInfo{Name: "Jonah Doe", Age: 50}).Post("/users", nil, nil)
+			assert.Nil(t, err)
+		}
+		rec.end()
+
+		// our data is correct?
+		assert.EqualValues(t, 1, atomic.LoadInt32(&requests))
+
+		stored, exists, err := loadCassette(cassette)
+		assert.Nil(t, err)
+		assert.True(t, exists)
+		assert.Len(t, stored.Interactions, 3)
+	})
+
+	t.Run("pass requests through", func(t *testing.T) {
+
+		rec := &recorder{}
+		atomic.StoreInt32(&requests, 0)
+		other := filepath.Join(dir, "passthrough.json")
+
+		// We create our requist Client
+		client := requist.New(server.URL)
+
+		_, err := Record(rec, client, other, ModePassthrough)
+		assert.Nil(t, err)
+
+		_, err = client.Get("/users/1", nil, nil)
+		assert.Nil(t, err)
+		rec.end()
+
+		// our data is correct?
+		assert.EqualValues(t, 1, atomic.LoadInt32(&requests))
+		_, err = os.Stat(other)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("fail replaying missing cassettes", func(t *testing.T) {
+
+		_, err := NewRecorder(filepath.Join(dir, "missing.json"), ModeReplay)
+
+		// our data is correct?
+		assert.NotNil(t, err)
+	})
+}
+
+func TestBody(t *testing.T) {
+
+	for _, body := range []Body{Body("plain text"), Body{0xff, 0x00, 0xfe}} {
+
+		encoded, err := json.Marshal(body)
+		assert.Nil(t, err)
+
+		var decoded Body
+		assert.Nil(t, json.Unmarshal(encoded, &decoded))
+
+		// our data is correct?
+		assert.Equal(t, body, decoded)
+	}
+}
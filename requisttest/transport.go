@@ -0,0 +1,335 @@
+// Package requisttest provides an in-memory mock transport and a recorder replaying cassettes of real interactions,
+// to test code using requist without servers nor network
+package requisttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dotWicho/requist"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+)
+
+// ErrUnexpectedRequest is returned for requests no expectation matches
+var ErrUnexpectedRequest = errors.New("unexpected request")
+
+// TestingT is the part of *testing.T used to report failures
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	Cleanup(func())
+}
+
+// Transport is a mock http.RoundTripper answering requests with the expectations registered on it
+type Transport struct {
+	t TestingT
+
+	mutex        sync.Mutex
+	expectations []*Expectation
+	calls        []*http.Request
+	ordered      bool
+}
+
+// NewTransport returns an empty mock Transport, unmet expectations are reported to t when the test ends
+func NewTransport(t TestingT) *Transport {
+
+	m := &Transport{t: t}
+	t.Cleanup(func() {
+		m.AssertExpectations()
+	})
+	return m
+}
+
+// NewClient returns a requist Client for baseURL sending its requests through a new mock Transport
+func NewClient(t TestingT, baseURL string) (*requist.Requist, *Transport) {
+
+	m := NewTransport(t)
+	client := requist.New(baseURL)
+	if client != nil {
+		client.SetClientRoundTripper(m)
+	}
+	return client, m
+}
+
+// On registers an expectation for method requests to path, empty method matches any. path may hold
+// path.Match patterns, ie: /users/*
+func (m *Transport) On(method, path string) *Expectation {
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	e := &Expectation{
+		method: strings.ToUpper(method),
+		path:   path,
+		query:  map[string][]string{},
+		header: http.Header{},
+		min:    1,
+		max:    1,
+		status: http.StatusOK,
+		reply:  http.Header{},
+	}
+	m.expectations = append(m.expectations, e)
+	return e
+}
+
+// InOrder requires expectations to be met in the order they were registered
+func (m *Transport) InOrder() *Transport {
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.ordered = true
+	return m
+}
+
+// Calls returns the requests received, in order
+func (m *Transport) Calls() []*http.Request {
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return append([]*http.Request(nil), m.calls...)
+}
+
+// AssertExpectations reports every expectation not called as many times as expected, false if any
+func (m *Transport) AssertExpectations() bool {
+
+	m.t.Helper()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	met := true
+	for _, e := range m.expectations {
+		if e.calls < e.min {
+			m.t.Errorf("requisttest: %s called %d times, expected %s", e, e.calls, e.expected())
+			met = false
+		}
+	}
+	return met
+}
+
+// RoundTrip implements http.RoundTripper answering with the first expectation matching request
+func (m *Transport) RoundTrip(request *http.Request) (*http.Response, error) {
+
+	m.t.Helper()
+
+	var body []byte
+	if request.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(request.Body); err != nil {
+			return nil, err
+		}
+		_ = request.Body.Close()
+		request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	e, err := m.match(request, body)
+	if err != nil {
+		m.t.Errorf("requisttest: %s", err)
+		return nil, err
+	}
+	return e.respond(request)
+}
+
+// match records request and returns the expectation answering it
+func (m *Transport) match(request *http.Request, body []byte) (*Expectation, error) {
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.calls = append(m.calls, request)
+
+	for i, e := range m.expectations {
+		if (e.max >= 0 && e.calls >= e.max) || !e.matches(request, body) {
+			continue
+		}
+		if m.ordered {
+			for _, previous := range m.expectations[:i] {
+				if previous.calls < previous.min {
+					return nil, fmt.Errorf("%s called before %s", e, previous)
+				}
+			}
+		}
+		e.calls++
+		return e, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrUnexpectedRequest, request.Method, request.URL)
+}
+
+// Expectation describes a request expected by a mock Transport and how to answer it
+type Expectation struct {
+
+	// What is expected
+	method  string
+	path    string
+	query   map[string][]string
+	header  http.Header
+	matcher func(body []byte) bool
+
+	// How many times, max below 0 means unlimited, and how many it was called
+	min   int
+	max   int
+	calls int
+
+	// How to answer
+	status int
+	reply  http.Header
+	body   []byte
+	err    error
+}
+
+// String describes the expectation
+func (e *Expectation) String() string {
+
+	method := e.method
+	if method == "" {
+		method = "*"
+	}
+	return method + " " + e.path
+}
+
+// expected describes how many times the expectation should be called
+func (e *Expectation) expected() string {
+
+	switch {
+	case e.max < 0:
+		return fmt.Sprintf("at least %d", e.min)
+	case e.min == e.max:
+		return fmt.Sprintf("%d", e.min)
+	}
+	return fmt.Sprintf("between %d and %d", e.min, e.max)
+}
+
+// WithQuery expects query param key to hold values, in order
+func (e *Expectation) WithQuery(key string, values ...string) *Expectation {
+
+	e.query[key] = values
+	return e
+}
+
+// WithHeader expects header key to hold value
+func (e *Expectation) WithHeader(key, value string) *Expectation {
+
+	e.header.Add(key, value)
+	return e
+}
+
+// WithBody expects matcher to accept the request body, see BodyEquals and JSONEquals
+func (e *Expectation) WithBody(matcher func(body []byte) bool) *Expectation {
+
+	e.matcher = matcher
+	return e
+}
+
+// Times expects the request exactly n times, once by default
+func (e *Expectation) Times(n int) *Expectation {
+
+	e.min, e.max = n, n
+	return e
+}
+
+// AnyTimes accepts the request any number of times, none included
+func (e *Expectation) AnyTimes() *Expectation {
+
+	e.min, e.max = 0, -1
+	return e
+}
+
+// Reply answers with status and body
+func (e *Expectation) Reply(status int, body string) *Expectation {
+
+	e.status = status
+	e.body = []byte(body)
+	e.err = nil
+	return e
+}
+
+// ReplyJSON answers with status and v encoded as JSON
+func (e *Expectation) ReplyJSON(status int, v interface{}) *Expectation {
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		e.err = err
+		return e
+	}
+	e.reply.Set("Content-Type", requist.JSONContentType)
+	return e.Reply(status, string(body))
+}
+
+// ReplyHeader adds header key with value to the answer
+func (e *Expectation) ReplyHeader(key, value string) *Expectation {
+
+	e.reply.Add(key, value)
+	return e
+}
+
+// ReplyError fails the request with err instead of answering
+func (e *Expectation) ReplyError(err error) *Expectation {
+
+	e.err = err
+	return e
+}
+
+// matches tells if request with body is the one expected
+func (e *Expectation) matches(request *http.Request, body []byte) bool {
+
+	if e.method != "" && e.method != request.Method {
+		return false
+	}
+	if ok, err := path.Match(e.path, request.URL.Path); err != nil || !ok {
+		return false
+	}
+
+	query := request.URL.Query()
+	for key, values := range e.query {
+		if strings.Join(query[key], "\x00") != strings.Join(values, "\x00") {
+			return false
+		}
+	}
+	for key, values := range e.header {
+		for _, value := range values {
+			if !contains(request.Header.Values(key), value) {
+				return false
+			}
+		}
+	}
+
+	return e.matcher == nil || e.matcher(body)
+}
+
+// respond builds the answer to request
+func (e *Expectation) respond(request *http.Request) (*http.Response, error) {
+
+	if e.err != nil {
+		return nil, e.err
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.status, http.StatusText(e.status)),
+		StatusCode:    e.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.reply.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       request,
+	}, nil
+}
+
+// contains tells if values holds value
+func contains(values []string, value string) bool {
+
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
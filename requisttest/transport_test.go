@@ -0,0 +1,169 @@
+package requisttest
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dotWicho/requist"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+// UserInfo, fictional user information
+type UserInfo struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+// recorder is a TestingT keeping failures and cleanups to check them
+type recorder struct {
+	failures []string
+	cleanups []func()
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Cleanup(f func()) {
+	r.cleanups = append(r.cleanups, f)
+}
+
+// end runs cleanups as the end of a test would
+func (r *recorder) end() {
+	for _, f := range r.cleanups {
+		f()
+	}
+}
+
+func TestTransport(t *testing.T) {
+
+	t.Run("answer matching expectations", func(t *testing.T) {
+
+		// We create our requist Client
+		client, mock := NewClient(t, "http://live.apitest.org")
+		mock.On(http.MethodGet, "/users/*").
+			WithQuery("fields", "name", "age").
+			WithHeader("Authorization", "Bearer token").
+			ReplyJSON(http.StatusOK, &UserInfo{Name: "Jonah Doe", Age: 50}).
+			ReplyHeader("ETag", `"v1"`)
+		mock.On(http.MethodPost, "/users").
+			WithBody(JSONEquals(map[string]interface{}{"age": 47, "name": "Jason Borne"})).
+			Reply(http.StatusCreated, `{"name": "Jason Borne", "age": 47}`)
+
+		client.Accept(requist.JSONContentType)
+		client.SetHeader("Authorization", "Bearer token")
+
+		user := &UserInfo{}
+		client.AddQueryParam("fields", "name")
+		client.AddQueryParam("fields", "age")
+		_, err := client.Get("/users/1", user, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, "Jonah Doe", user.Name)
+		assert.Equal(t, `"v1"`, client.ETag())
+
+		created := &UserInfo{}
+		_, err = client.BodyAsJSON(&UserInfo{Name: "Jason Borne", Age: 47}).Post("/users", created, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusCreated, client.StatusCode())
+		assert.Equal(t, 47, created.Age)
+		assert.Len(t, mock.Calls(), 2)
+	})
+
+	t.Run("fail requests with errors", func(t *testing.T) {
+
+		failure := errors.New("connection reset")
+
+		// We create our requist Client
+		client, mock := NewClient(t, "http://live.apitest.org")
+		mock.On(http.MethodGet, "/users/1").ReplyError(failure)
+
+		_, err := client.Get("/users/1", nil, nil)
+
+		// our data is correct?
+		assert.True(t, errors.Is(err, failure))
+	})
+
+	t.Run("count calls", func(t *testing.T) {
+
+		// We create our requist Client
+		client, mock := NewClient(t, "http://live.apitest.org")
+		mock.On(http.MethodGet, "/ping").Times(2).Reply(http.StatusNoContent, "")
+		mock.On(http.MethodGet, "/health").AnyTimes().Reply(http.StatusNoContent, "")
+
+		for i := 0; i < 2; i++ {
+			_, err := client.Get("/ping", nil, nil)
+			assert.Nil(t, err)
+		}
+	})
+
+	t.Run("report unexpected requests", func(t *testing.T) {
+
+		rec := &recorder{}
+
+		// We create our requist Client
+		client, mock := NewClient(rec, "http://live.apitest.org")
+		mock.On(http.MethodGet, "/ping").Reply(http.StatusNoContent, "")
+
+		_, err := client.Get("/ping", nil, nil)
+		assert.Nil(t, err)
+		_, err = client.Get("/ping", nil, nil)
+
+		// our data is correct?
+		assert.True(t, errors.Is(err, ErrUnexpectedRequest))
+		assert.Len(t, rec.failures, 1)
+	})
+
+	t.Run("report unmet expectations at test end", func(t *testing.T) {
+
+		rec := &recorder{}
+
+		// We create our requist Client
+		client, mock := NewClient(rec, "http://live.apitest.org")
+		mock.On(http.MethodGet, "/ping").Times(2).Reply(http.StatusNoContent, "")
+		mock.On(http.MethodDelete, "/users/1").Reply(http.StatusNoContent, "")
+
+		_, err := client.Get("/ping", nil, nil)
+		assert.Nil(t, err)
+		rec.end()
+
+		// our data is correct?
+		assert.Equal(t, []string{
+			"requisttest: GET /ping called 1 times, expected 2",
+			"requisttest: DELETE /users/1 called 0 times, expected 1",
+		}, rec.failures)
+	})
+
+	t.Run("report calls out of order", func(t *testing.T) {
+
+		rec := &recorder{}
+
+		// We create our requist Client
+		client, mock := NewClient(rec, "http://live.apitest.org")
+		mock.InOrder()
+		mock.On(http.MethodPost, "/login").Reply(http.StatusNoContent, "")
+		mock.On(http.MethodGet, "/profile").Reply(http.StatusNoContent, "")
+
+		_, err := client.Get("/profile", nil, nil)
+
+		// our data is correct?
+		assert.NotNil(t, err)
+		assert.Equal(t, []string{"requisttest: GET /profile called before POST /login"}, rec.failures)
+	})
+}
+
+func TestBodyEquals(t *testing.T) {
+
+	// our data is correct?
+	assert.True(t, BodyEquals("name=Jonah")([]byte("name=Jonah")))
+	assert.False(t, BodyEquals("name=Jonah")([]byte("name=Jason")))
+	assert.True(t, JSONEquals(&UserInfo{Name: "Jonah Doe", Age: 50})([]byte(`{ "age": 50, "name": "Jonah Doe" }`)))
+	assert.False(t, JSONEquals(&UserInfo{Name: "Jonah Doe", Age: 50})([]byte(`{"name": "Jonah Doe"}`)))
+	assert.False(t, JSONEquals(&UserInfo{})([]byte(`not json`)))
+}
@@ -0,0 +1,193 @@
+package requist
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+//=== Custom name resolution
+
+// dnsEntry is a cached name resolution
+type dnsEntry struct {
+	addresses []string
+	expires   time.Time
+}
+
+// Dialer opens client connections honoring host overrides, a custom resolver and a DNS cache.
+// Only the dialed address changes, so TLS SNI and certificate verification keep using the requested host
+type Dialer struct {
+	mutex     sync.RWMutex
+	dialer    *net.Dialer
+	overrides map[string][]string
+	resolver  *net.Resolver
+	ttl       time.Duration
+	cache     map[string]dnsEntry
+	lookup    func(ctx context.Context, host string) ([]string, error)
+}
+
+// NewDialer returns a Dialer with cleanhttp default timeouts
+func NewDialer() *Dialer {
+
+	d := &Dialer{
+		dialer: &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		},
+		overrides: map[string][]string{},
+		cache:     map[string]dnsEntry{},
+	}
+	d.lookup = d.lookupHost
+	return d
+}
+
+// Resolve pins host (as host or host:port) to addresses, given as ip or ip:port. No addresses removes the override
+func (d *Dialer) Resolve(host string, addresses ...string) {
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if len(addresses) == 0 {
+		delete(d.overrides, host)
+		return
+	}
+	d.overrides[host] = addresses
+}
+
+// SetResolver sets the resolver used to lookup hosts, nil means net.DefaultResolver
+func (d *Dialer) SetResolver(resolver *net.Resolver) {
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.resolver = resolver
+	d.cache = map[string]dnsEntry{}
+}
+
+// SetCacheTTL keeps lookups results for ttl, zero disables the cache
+func (d *Dialer) SetCacheTTL(ttl time.Duration) {
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.ttl = ttl
+	d.cache = map[string]dnsEntry{}
+}
+
+// DialContext connects to address on network, trying every resolved address in order
+func (d *Dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := d.addresses(ctx, host, port)
+	if err != nil {
+		return nil, err
+	}
+
+	var conn net.Conn
+	for _, target := range addresses {
+		if _, _, err := net.SplitHostPort(target); err != nil {
+			target = net.JoinHostPort(target, port)
+		}
+		if conn, err = d.dialer.DialContext(ctx, network, target); err == nil {
+			return conn, nil
+		}
+		Logger.Debug("Unable to dial %s for %s: %s", target, address, err)
+	}
+	return nil, err
+}
+
+// addresses returns where host:port must be dialed
+func (d *Dialer) addresses(ctx context.Context, host, port string) ([]string, error) {
+
+	d.mutex.RLock()
+	overrides, ok := d.overrides[net.JoinHostPort(host, port)]
+	if !ok {
+		overrides, ok = d.overrides[host]
+	}
+	resolver, ttl := d.resolver, d.ttl
+	cached, hit := d.cache[host]
+	d.mutex.RUnlock()
+
+	switch {
+	case ok:
+		return overrides, nil
+	case net.ParseIP(host) != nil || (resolver == nil && ttl == 0):
+		// Nothing to do on our side, let net.Dialer handle it
+		return []string{host}, nil
+	case hit && time.Now().Before(cached.expires):
+		return cached.addresses, nil
+	}
+
+	addresses, err := d.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("no addresses found for %s", host)
+	}
+
+	if ttl > 0 {
+		d.mutex.Lock()
+		d.cache[host] = dnsEntry{addresses: addresses, expires: time.Now().Add(ttl)}
+		d.mutex.Unlock()
+	}
+	return addresses, nil
+}
+
+// lookupHost resolves host using our resolver
+func (d *Dialer) lookupHost(ctx context.Context, host string) ([]string, error) {
+
+	d.mutex.RLock()
+	resolver := d.resolver
+	d.mutex.RUnlock()
+
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return resolver.LookupHost(ctx, host)
+}
+
+//=== Requist integration
+
+// clientDialer returns the client Dialer, installing it on the client Transport if missing
+func (r *Requist) clientDialer() *Dialer {
+
+	if r.dialer == nil {
+		r.dialer = NewDialer()
+	}
+	if r.socket == "" {
+		r.transport().DialContext = r.dialer.DialContext
+	}
+	return r.dialer
+}
+
+// SetClientResolve take host (as host or host:port) and pin it to addresses like curl --resolve does,
+// no addresses removes the override
+func (r *Requist) SetClientResolve(host string, addresses ...string) {
+
+	Logger.Debug("Setting Client Resolve %s to %v", host, addresses)
+
+	r.clientDialer().Resolve(host, addresses...)
+}
+
+// SetClientResolver take resolver param and use it to lookup hosts, nil means net.DefaultResolver
+func (r *Requist) SetClientResolver(resolver *net.Resolver) {
+
+	Logger.Debug("Setting Client Resolver")
+
+	r.clientDialer().SetResolver(resolver)
+}
+
+// SetClientDNSCache take ttl param and cache lookups results for that long, zero disables it
+func (r *Requist) SetClientDNSCache(ttl time.Duration) {
+
+	Logger.Debug("Setting Client DNS Cache %s", ttl)
+
+	r.clientDialer().SetCacheTTL(ttl)
+}
@@ -0,0 +1,139 @@
+package requist
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRequist_SetClientResolve(t *testing.T) {
+
+	// We create a Mock Server
+	server := MockHTTPServer()
+	defer server.Close()
+
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	t.Run("dial overridden host:port", func(t *testing.T) {
+
+		success := &UserInfo{}
+
+		// We create our requist Client
+		emptyClient := New("http://api.example:" + port)
+		emptyClient.Accept(JSONContentType)
+		emptyClient.SetClientResolve("api.example:"+port, "127.0.0.1")
+
+		_, err := emptyClient.Get("/user/1000", success, nil)
+
+		// if client return not Nil?
+		assert.Nil(t, err)
+
+		// our data is correct?
+		assert.EqualValues(t, "Jonah Doe", success.Name)
+	})
+
+	t.Run("fail over to next address", func(t *testing.T) {
+
+		success := &UserInfo{}
+
+		// We create our requist Client
+		emptyClient := New("http://api.example")
+		emptyClient.Accept(JSONContentType)
+		emptyClient.SetClientResolve("api.example", "127.0.0.1:1", server.Listener.Addr().String())
+
+		_, err := emptyClient.Get("/user/1000", success, nil)
+
+		// if client return not Nil?
+		assert.Nil(t, err)
+
+		// our data is correct?
+		assert.EqualValues(t, "Jonah Doe", success.Name)
+	})
+
+	t.Run("keep SNI and certificate verification", func(t *testing.T) {
+
+		pki := newMockPKI(t)
+
+		// We create a Mock Server with a certificate valid only for api.internal
+		secure := MockTLSServer(pki, tls.NoClientCert, "api.internal")
+		defer secure.Close()
+
+		secureURL, _ := url.Parse(secure.URL)
+
+		// We create our requist Client
+		emptyClient := New("https://api.internal:" + secureURL.Port())
+		assert.Nil(t, emptyClient.SetClientRootCAs(pki.CAPEM))
+		emptyClient.SetClientResolve("api.internal", secureURL.Hostname())
+
+		_, err := emptyClient.Get("/", nil, nil)
+
+		// if client return not Nil?
+		assert.Nil(t, err)
+		assert.EqualValues(t, http.StatusOK, emptyClient.StatusCode())
+	})
+}
+
+func TestRequist_SetClientResolver(t *testing.T) {
+
+	failure := errors.New("resolver unreachable")
+
+	// We create our requist Client
+	emptyClient := New("http://api.example")
+	emptyClient.SetClientResolver(&net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return nil, failure
+		},
+	})
+
+	_, err := emptyClient.Get("/", nil, nil)
+
+	// our resolver was used?
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), failure.Error())
+}
+
+func TestDialer_SetCacheTTL(t *testing.T) {
+
+	// We create a Mock Server
+	server := MockHTTPServer()
+	defer server.Close()
+
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	// We create our Dialer counting lookups
+	lookups := 0
+	dialer := NewDialer()
+	dialer.lookup = func(ctx context.Context, host string) ([]string, error) {
+		lookups++
+		return []string{"127.0.0.1"}, nil
+	}
+	dialer.SetCacheTTL(time.Minute)
+
+	for i := 0; i < 3; i++ {
+		conn, err := dialer.DialContext(context.Background(), "tcp", "api.example:"+port)
+		assert.Nil(t, err)
+		_ = conn.Close()
+	}
+
+	// our data is correct?
+	assert.EqualValues(t, 1, lookups)
+
+	// an expired entry must be looked up again
+	dialer.SetCacheTTL(time.Nanosecond)
+	for i := 0; i < 2; i++ {
+		time.Sleep(time.Millisecond)
+		conn, err := dialer.DialContext(context.Background(), "tcp", "api.example:"+port)
+		assert.Nil(t, err)
+		_ = conn.Close()
+	}
+
+	// our data is correct?
+	assert.EqualValues(t, 3, lookups)
+}
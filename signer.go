@@ -0,0 +1,120 @@
+package requist
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//=== Request signing
+
+// Signer signs an outgoing request once its body has been materialized
+type Signer interface {
+	// Sign adds the signature to the request, body holds a copy of the request payload
+	Sign(request *http.Request, body []byte) error
+}
+
+//=== HMACSigner implementation of Signer interface
+
+const (
+	// defaultSignatureHeader is the header where HMACSigner stores the signature
+	defaultSignatureHeader string = "X-Signature"
+	// defaultTimestampHeader is the header where HMACSigner stores the timestamp signed
+	defaultTimestampHeader string = "X-Timestamp"
+	// defaultSignTemplate is the canonical string signed by HMACSigner
+	defaultSignTemplate string = "{method}\n{path}\n{timestamp}\n{body}"
+)
+
+// HMACSigner signs requests computing an HMAC over a canonical string built from Template,
+// where placeholders {method}, {host}, {path}, {query}, {timestamp} and {body} are replaced
+type HMACSigner struct {
+	// Key is the shared secret
+	Key []byte
+	// Algorithm returns the hash used by the HMAC, sha256.New by default
+	Algorithm func() hash.Hash
+	// SignatureHeader holds the signature, X-Signature by default
+	SignatureHeader string
+	// TimestampHeader holds the timestamp signed, X-Timestamp by default. Empty disables it
+	TimestampHeader string
+	// Prefix is prepended to the encoded signature (ie: "sha256=")
+	Prefix string
+	// Template is the canonical string to be signed
+	Template string
+	// Encode converts the raw signature into its header representation, hex by default
+	Encode func(signature []byte) string
+	// Clock returns the current time, time.Now by default
+	Clock func() time.Time
+}
+
+// NewHMACSigner returns an HMACSigner with sane defaults for key
+func NewHMACSigner(key []byte) *HMACSigner {
+
+	return &HMACSigner{
+		Key:             key,
+		Algorithm:       sha256.New,
+		SignatureHeader: defaultSignatureHeader,
+		TimestampHeader: defaultTimestampHeader,
+		Template:        defaultSignTemplate,
+		Encode:          hex.EncodeToString,
+		Clock:           time.Now,
+	}
+}
+
+// Canonical returns the string to be signed for request, body and timestamp
+func (s *HMACSigner) Canonical(request *http.Request, body []byte, timestamp string) string {
+
+	template := s.Template
+	if template == "" {
+		template = defaultSignTemplate
+	}
+
+	replacer := strings.NewReplacer(
+		"{method}", request.Method,
+		"{host}", request.URL.Host,
+		"{path}", request.URL.EscapedPath(),
+		"{query}", request.URL.RawQuery,
+		"{timestamp}", timestamp,
+		"{body}", string(body),
+	)
+	return replacer.Replace(template)
+}
+
+// Sign computes the HMAC of request and sets the configured headers
+func (s *HMACSigner) Sign(request *http.Request, body []byte) error {
+
+	algorithm := s.Algorithm
+	if algorithm == nil {
+		algorithm = sha256.New
+	}
+	clock := s.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+	encode := s.Encode
+	if encode == nil {
+		encode = hex.EncodeToString
+	}
+	header := s.SignatureHeader
+	if header == "" {
+		header = defaultSignatureHeader
+	}
+
+	timestamp := strconv.FormatInt(clock().Unix(), 10)
+
+	mac := hmac.New(algorithm, s.Key)
+	if _, err := mac.Write([]byte(s.Canonical(request, body, timestamp))); err != nil {
+		return err
+	}
+
+	if s.TimestampHeader != "" {
+		request.Header.Set(s.TimestampHeader, timestamp)
+	}
+	request.Header.Set(header, s.Prefix+encode(mac.Sum(nil)))
+
+	return nil
+}
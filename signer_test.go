@@ -0,0 +1,100 @@
+package requist
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHMACSigner_Sign(t *testing.T) {
+
+	// We define some variables
+	key := []byte("s3cr3t")
+	clock := func() time.Time { return time.Unix(1600000000, 0) }
+
+	t.Run("set signature and timestamp headers", func(t *testing.T) {
+
+		signer := NewHMACSigner(key)
+		signer.Clock = clock
+
+		request := httptest.NewRequest(http.MethodPost, "http://live.apitest.org/hooks?x=1", nil)
+		err := signer.Sign(request, []byte(`{"id":1}`))
+
+		// if signer return not Nil?
+		assert.Nil(t, err)
+
+		// our data is correct?
+		mac := hmac.New(sha256.New, key)
+		_, _ = mac.Write([]byte("POST\n/hooks\n1600000000\n{\"id\":1}"))
+
+		assert.EqualValues(t, "1600000000", request.Header.Get("X-Timestamp"))
+		assert.EqualValues(t, hex.EncodeToString(mac.Sum(nil)), request.Header.Get("X-Signature"))
+	})
+
+	t.Run("use custom headers, prefix and template", func(t *testing.T) {
+
+		signer := NewHMACSigner(key)
+		signer.Clock = clock
+		signer.SignatureHeader = "X-Hub-Signature-256"
+		signer.TimestampHeader = ""
+		signer.Prefix = "sha256="
+		signer.Template = "{method} {path}?{query}"
+
+		request := httptest.NewRequest(http.MethodGet, "http://live.apitest.org/hooks?x=1", nil)
+		err := signer.Sign(request, nil)
+
+		// if signer return not Nil?
+		assert.Nil(t, err)
+
+		// our data is correct?
+		mac := hmac.New(sha256.New, key)
+		_, _ = mac.Write([]byte("GET /hooks?x=1"))
+
+		assert.Empty(t, request.Header.Get("X-Timestamp"))
+		assert.EqualValues(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), request.Header.Get("X-Hub-Signature-256"))
+	})
+}
+
+func TestRequist_SetSigner(t *testing.T) {
+
+	// We create a Mock Server that echoes the signature and body received
+	var signature, received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		signature = r.Header.Get("X-Signature")
+		received = string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	signer := NewHMACSigner([]byte("s3cr3t"))
+	signer.Clock = func() time.Time { return time.Unix(1600000000, 0) }
+
+	// We create our requist Client
+	emptyClient := New(server.URL).SetSigner(signer)
+
+	// was modified out Client?
+	assert.NotNil(t, emptyClient)
+	assert.EqualValues(t, signer, emptyClient.signer)
+
+	// fire up the request
+	_, err := emptyClient.BodyAsJSON(&UserInfo{Name: "Jonah Doe", Age: 47}).Post("/hooks", nil, nil)
+
+	// if client return not Nil?
+	assert.Nil(t, err)
+
+	// body must be delivered untouched after signing it
+	assert.EqualValues(t, "{\"name\":\"Jonah Doe\",\"age\":47}\n", received)
+
+	mac := hmac.New(sha256.New, []byte("s3cr3t"))
+	_, _ = mac.Write([]byte("POST\n/hooks\n1600000000\n" + received))
+
+	// our data is correct?
+	assert.EqualValues(t, hex.EncodeToString(mac.Sum(nil)), signature)
+}
@@ -0,0 +1,182 @@
+package requist
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+//=== Cache storage backends
+
+// defaultMemoryEntries is the number of responses kept by a MemoryCache when none is given
+const defaultMemoryEntries = 1000
+
+// CachedResponse is a response stored by Cache
+type CachedResponse struct {
+	// Key identifies the request, its method and URL
+	Key string `json:"key"`
+	// StatusCode of the stored response
+	StatusCode int `json:"status_code"`
+	// Header of the stored response
+	Header http.Header `json:"header"`
+	// Body of the stored response
+	Body []byte `json:"body"`
+	// Vary holds the request headers nominated by the response Vary header
+	Vary http.Header `json:"vary,omitempty"`
+	// RequestTime is when the request was sent
+	RequestTime time.Time `json:"request_time"`
+	// ResponseTime is when the response was received
+	ResponseTime time.Time `json:"response_time"`
+}
+
+// CacheStore is where Cache keeps responses. Stored responses must be treated as read only,
+// implementations must be safe for concurrent use
+type CacheStore interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, response *CachedResponse)
+	Delete(key string)
+}
+
+//=== MemoryCache implementation of CacheStore interface
+
+// MemoryCache is an in-memory CacheStore evicting least recently used responses
+type MemoryCache struct {
+	mutex      sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List
+}
+
+// NewMemoryCache returns a MemoryCache keeping up to maxEntries responses, 1000 if zero
+func NewMemoryCache(maxEntries int) *MemoryCache {
+
+	if maxEntries <= 0 {
+		maxEntries = defaultMemoryEntries
+	}
+
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		entries:    map[string]*list.Element{},
+		order:      list.New(),
+	}
+}
+
+// Len returns the number of responses stored
+func (m *MemoryCache) Len() int {
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.order.Len()
+}
+
+// Get implements CacheStore interface
+func (m *MemoryCache) Get(key string) (*CachedResponse, bool) {
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	element, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	m.order.MoveToFront(element)
+	return element.Value.(*CachedResponse), true
+}
+
+// Set implements CacheStore interface
+func (m *MemoryCache) Set(key string, response *CachedResponse) {
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if element, ok := m.entries[key]; ok {
+		element.Value = response
+		m.order.MoveToFront(element)
+		return
+	}
+
+	m.entries[key] = m.order.PushFront(response)
+	for m.order.Len() > m.maxEntries {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*CachedResponse).Key)
+	}
+}
+
+// Delete implements CacheStore interface
+func (m *MemoryCache) Delete(key string) {
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if element, ok := m.entries[key]; ok {
+		m.order.Remove(element)
+		delete(m.entries, key)
+	}
+}
+
+//=== DiskCache implementation of CacheStore interface
+
+// DiskCache is a CacheStore keeping every response as a JSON file inside a directory
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache returns a DiskCache using dir, creating it if missing
+func NewDiskCache(dir string) (*DiskCache, error) {
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+// filename returns where key is stored
+func (d *DiskCache) filename(key string) string {
+
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get implements CacheStore interface
+func (d *DiskCache) Get(key string) (*CachedResponse, bool) {
+
+	content, err := ioutil.ReadFile(d.filename(key))
+	if err != nil {
+		return nil, false
+	}
+
+	response := &CachedResponse{}
+	if err = json.Unmarshal(content, response); err != nil || response.Key != key {
+		return nil, false
+	}
+	return response, true
+}
+
+// Set implements CacheStore interface
+func (d *DiskCache) Set(key string, response *CachedResponse) {
+
+	content, err := json.Marshal(response)
+	if err == nil {
+		err = writeFile(d.filename(key), content)
+	}
+	if err != nil {
+		Logger.Warn("Unable to store %s into %s: %s", key, d.dir, err)
+	}
+}
+
+// Delete implements CacheStore interface
+func (d *DiskCache) Delete(key string) {
+
+	if err := os.Remove(d.filename(key)); err != nil && !os.IsNotExist(err) {
+		Logger.Warn("Unable to remove %s from %s: %s", key, d.dir, err)
+	}
+}
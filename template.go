@@ -0,0 +1,342 @@
+package requist
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+//=== URI templates (RFC 6570)
+
+var (
+	// ErrInvalidTemplate is returned when a template can't be parsed
+	ErrInvalidTemplate = errors.New("invalid URI template")
+	// ErrMissingVariable is returned when a path variable has no value
+	ErrMissingVariable = errors.New("missing URI template variable")
+)
+
+// templateOperator holds how an expression operator expands its variables (RFC 6570 appendix A)
+type templateOperator struct {
+	first    string
+	sep      string
+	named    bool
+	ifEmpty  string
+	reserved bool
+	optional bool
+}
+
+// templateOperators are the operators supported, query ones don't require their variables
+var templateOperators = map[byte]templateOperator{
+	'+': {first: "", sep: ",", reserved: true},
+	'#': {first: "#", sep: ",", reserved: true, optional: true},
+	'.': {first: ".", sep: "."},
+	'/': {first: "/", sep: "/"},
+	';': {first: ";", sep: ";", named: true},
+	'?': {first: "?", sep: "&", named: true, ifEmpty: "=", optional: true},
+	'&': {first: "&", sep: "&", named: true, ifEmpty: "=", optional: true},
+}
+
+// templateVariable is a varspec inside an expression
+type templateVariable struct {
+	name    string
+	prefix  int
+	explode bool
+}
+
+// templateValue is a variable value, either a string, a list or an associative array
+type templateValue struct {
+	str   *string
+	list  []string
+	pairs [][2]string
+}
+
+// ExpandTemplate expands an RFC 6570 URI template (ie: /users/{id}/repos{?type,sort}) with params,
+// a map or a struct with url tags. Variables outside query and fragment expressions are required
+func ExpandTemplate(template string, params interface{}) (string, error) {
+
+	values, err := templateParams(params)
+	if err != nil {
+		return "", err
+	}
+
+	var expanded strings.Builder
+	for len(template) > 0 {
+		open := strings.IndexAny(template, "{}")
+		if open < 0 {
+			expanded.WriteString(encodeTemplate(template, true))
+			break
+		}
+		if template[open] == '}' {
+			return "", fmt.Errorf("%w: unexpected } at %q", ErrInvalidTemplate, template)
+		}
+		expanded.WriteString(encodeTemplate(template[:open], true))
+
+		end := strings.IndexByte(template[open:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("%w: unclosed expression %q", ErrInvalidTemplate, template[open:])
+		}
+		result, err := expandExpression(template[open+1:open+end], values)
+		if err != nil {
+			return "", err
+		}
+		expanded.WriteString(result)
+		template = template[open+end+1:]
+	}
+	return expanded.String(), nil
+}
+
+// expandExpression expands the content of a single {expression}
+func expandExpression(expression string, values map[string]templateValue) (string, error) {
+
+	if expression == "" {
+		return "", fmt.Errorf("%w: empty expression", ErrInvalidTemplate)
+	}
+
+	operator := templateOperator{sep: ","}
+	if op, ok := templateOperators[expression[0]]; ok {
+		operator = op
+		expression = expression[1:]
+	} else if strings.ContainsRune("=,!@|", rune(expression[0])) {
+		return "", fmt.Errorf("%w: reserved operator %q", ErrInvalidTemplate, expression[0])
+	}
+
+	var expanded []string
+	for _, spec := range strings.Split(expression, ",") {
+		variable, err := parseVariable(spec)
+		if err != nil {
+			return "", err
+		}
+
+		value, ok := values[variable.name]
+		if !ok {
+			if operator.optional {
+				continue
+			}
+			return "", fmt.Errorf("%w: %s", ErrMissingVariable, variable.name)
+		}
+		if result, defined := expandVariable(operator, variable, value); defined {
+			expanded = append(expanded, result)
+		}
+	}
+
+	if len(expanded) == 0 {
+		return "", nil
+	}
+	return operator.first + strings.Join(expanded, operator.sep), nil
+}
+
+// parseVariable parses a varspec: name, name:prefix or name*
+func parseVariable(spec string) (templateVariable, error) {
+
+	variable := templateVariable{name: spec}
+	if strings.HasSuffix(spec, "*") {
+		variable.name, variable.explode = strings.TrimSuffix(spec, "*"), true
+	} else if i := strings.IndexByte(spec, ':'); i >= 0 {
+		variable.name = spec[:i]
+		if _, err := fmt.Sscanf(spec[i+1:], "%d", &variable.prefix); err != nil || variable.prefix <= 0 || variable.prefix >= 10000 {
+			return variable, fmt.Errorf("%w: invalid prefix %q", ErrInvalidTemplate, spec)
+		}
+	}
+
+	if variable.name == "" {
+		return variable, fmt.Errorf("%w: invalid variable %q", ErrInvalidTemplate, spec)
+	}
+	for _, c := range variable.name {
+		if !(c == '_' || c == '.' || c == '%' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')) {
+			return variable, fmt.Errorf("%w: invalid variable %q", ErrInvalidTemplate, spec)
+		}
+	}
+	return variable, nil
+}
+
+// expandVariable expands a single variable, false if its value counts as undefined
+func expandVariable(operator templateOperator, variable templateVariable, value templateValue) (string, bool) {
+
+	encode := func(s string) string { return encodeTemplate(s, operator.reserved) }
+	named := func(name, s string) string {
+		if !operator.named {
+			return s
+		}
+		if s == "" {
+			return name + operator.ifEmpty
+		}
+		return name + "=" + s
+	}
+
+	switch {
+	case value.str != nil:
+		s := *value.str
+		if variable.prefix > 0 && len([]rune(s)) > variable.prefix {
+			s = string([]rune(s)[:variable.prefix])
+		}
+		return named(variable.name, encode(s)), true
+
+	case len(value.list) > 0:
+		items := make([]string, len(value.list))
+		for i, item := range value.list {
+			items[i] = encode(item)
+			if variable.explode {
+				items[i] = named(variable.name, items[i])
+			}
+		}
+		if variable.explode {
+			return strings.Join(items, operator.sep), true
+		}
+		return named(variable.name, strings.Join(items, ",")), true
+
+	case len(value.pairs) > 0:
+		items := make([]string, 0, 2*len(value.pairs))
+		for _, pair := range value.pairs {
+			if variable.explode {
+				items = append(items, encode(pair[0])+"="+encode(pair[1]))
+			} else {
+				items = append(items, encode(pair[0]), encode(pair[1]))
+			}
+		}
+		if variable.explode {
+			return strings.Join(items, operator.sep), true
+		}
+		return named(variable.name, strings.Join(items, ",")), true
+	}
+	return "", false
+}
+
+// encodeTemplate percent-encodes s, keeping reserved characters and pct-encoded triplets when reserved is set
+func encodeTemplate(s string, reserved bool) string {
+
+	const hex = "0123456789ABCDEF"
+
+	var encoded strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || strings.IndexByte("-._~", c) >= 0:
+			encoded.WriteByte(c)
+		case reserved && strings.IndexByte(":/?#[]@!$&'()*+,;=", c) >= 0:
+			encoded.WriteByte(c)
+		case reserved && c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			encoded.WriteString(s[i : i+3])
+			i += 2
+		default:
+			encoded.WriteByte('%')
+			encoded.WriteByte(hex[c>>4])
+			encoded.WriteByte(hex[c&15])
+		}
+	}
+	return encoded.String()
+}
+
+// isHex tells if c is an hexadecimal digit
+func isHex(c byte) bool {
+
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+// templateParams converts a map or a struct with url tags into template values
+func templateParams(params interface{}) (map[string]templateValue, error) {
+
+	values := map[string]templateValue{}
+	if params == nil {
+		return values, nil
+	}
+
+	v := reflect.ValueOf(params)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return values, nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("%w: params keys must be strings, got %s", ErrInvalidTemplate, v.Type())
+		}
+		for _, key := range v.MapKeys() {
+			if value, ok := toTemplateValue(v.MapIndex(key)); ok {
+				values[key.String()] = value
+			}
+		}
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			name := field.Name
+			omitEmpty := false
+			if tag := field.Tag.Get("url"); tag != "" {
+				options := strings.Split(tag, ",")
+				if options[0] == "-" {
+					continue
+				}
+				if options[0] != "" {
+					name = options[0]
+				}
+				for _, option := range options[1:] {
+					omitEmpty = omitEmpty || option == "omitempty"
+				}
+			}
+			if omitEmpty && v.Field(i).IsZero() {
+				continue
+			}
+			if value, ok := toTemplateValue(v.Field(i)); ok {
+				values[name] = value
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("%w: params must be a map or a struct, got %s", ErrInvalidTemplate, v.Type())
+	}
+	return values, nil
+}
+
+// toTemplateValue converts v into a string, a list or an associative array, false if undefined
+func toTemplateValue(v reflect.Value) (templateValue, bool) {
+
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return templateValue{}, false
+		}
+		v = v.Elem()
+	}
+
+	// Values knowing how to print themselves (ie: time.Time) are taken as strings
+	if stringer, ok := v.Interface().(fmt.Stringer); ok {
+		s := stringer.String()
+		return templateValue{str: &s}, true
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return templateValue{}, false
+		}
+		list := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			if item, ok := toTemplateValue(v.Index(i)); ok && item.str != nil {
+				list = append(list, *item.str)
+			}
+		}
+		return templateValue{list: list}, true
+
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface()) })
+		pairs := make([][2]string, 0, len(keys))
+		for _, key := range keys {
+			if item, ok := toTemplateValue(v.MapIndex(key)); ok && item.str != nil {
+				pairs = append(pairs, [2]string{fmt.Sprint(key.Interface()), *item.str})
+			}
+		}
+		return templateValue{pairs: pairs}, true
+	}
+
+	s := fmt.Sprint(v.Interface())
+	return templateValue{str: &s}, true
+}
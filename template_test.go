@@ -0,0 +1,156 @@
+package requist
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestExpandTemplate(t *testing.T) {
+
+	// Variables and expected outcomes from RFC 6570 section 3.2
+	params := map[string]interface{}{
+		"var":   "value",
+		"hello": "Hello World!",
+		"path":  "/foo/bar",
+		"empty": "",
+		"x":     1024,
+		"y":     768,
+		"list":  []string{"red", "green", "blue"},
+		"keys":  map[string]string{"semi": ";", "dot": ".", "comma": ","},
+	}
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{"{var}", "value"},
+		{"{hello}", "Hello%20World%21"},
+		{"{+hello}", "Hello%20World!"},
+		{"{+path}/here", "/foo/bar/here"},
+		{"here?ref={+path}", "here?ref=/foo/bar"},
+		{"{#hello}", "#Hello%20World!"},
+		{"{var:3}", "val"},
+		{"map?{x,y}", "map?1024,768"},
+		{"{list}", "red,green,blue"},
+		{"{list*}", "red,green,blue"},
+		{"{keys}", "comma,%2C,dot,.,semi,%3B"},
+		{"{keys*}", "comma=%2C,dot=.,semi=%3B"},
+		{"X{.var}", "X.value"},
+		{"X{.list*}", "X.red.green.blue"},
+		{"{/var,x}/here", "/value/1024/here"},
+		{"{/list*}", "/red/green/blue"},
+		{"{;x,y,empty}", ";x=1024;y=768;empty"},
+		{"{;list*}", ";list=red;list=green;list=blue"},
+		{"{?x,y,empty}", "?x=1024&y=768&empty="},
+		{"{?list}", "?list=red,green,blue"},
+		{"{?list*}", "?list=red&list=green&list=blue"},
+		{"{?keys*}", "?comma=%2C&dot=.&semi=%3B"},
+		{"?fixed=yes{&x}", "?fixed=yes&x=1024"},
+		{"{?undef,x}", "?x=1024"},
+		{"/users/{var}/repos{?undef}", "/users/value/repos"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.template, func(t *testing.T) {
+
+			result, err := ExpandTemplate(test.template, params)
+
+			// our data is correct?
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, result)
+		})
+	}
+
+	t.Run("escape path segments", func(t *testing.T) {
+
+		result, err := ExpandTemplate("/users/{id}", map[string]string{"id": "a/b?c#d"})
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, "/users/a%2Fb%3Fc%23d", result)
+	})
+
+	t.Run("use struct url tags", func(t *testing.T) {
+
+		params := struct {
+			ID    int       `url:"id"`
+			Type  string    `url:"type,omitempty"`
+			Sort  []string  `url:"sort"`
+			Since time.Time `url:"since,omitempty"`
+			Skip  string    `url:"-"`
+		}{ID: 42, Sort: []string{"name", "date"}, Skip: "skip"}
+
+		result, err := ExpandTemplate("/users/{id}/repos{?type,sort,since,Skip}", params)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, "/users/42/repos?sort=name,date", result)
+	})
+
+	t.Run("fail on missing variables", func(t *testing.T) {
+
+		_, err := ExpandTemplate("/users/{id}/repos", map[string]string{})
+
+		// our data is correct?
+		assert.True(t, errors.Is(err, ErrMissingVariable))
+	})
+
+	t.Run("fail on invalid templates", func(t *testing.T) {
+
+		for _, template := range []string{"/users/{id", "/users/id}", "{}", "{=id}", "{id:0}", "{i d}"} {
+			_, err := ExpandTemplate(template, map[string]string{"id": "1"})
+
+			// our data is correct?
+			assert.True(t, errors.Is(err, ErrInvalidTemplate), template)
+		}
+	})
+
+	t.Run("fail on invalid params", func(t *testing.T) {
+
+		_, err := ExpandTemplate("/users/{id}", 42)
+
+		// our data is correct?
+		assert.True(t, errors.Is(err, ErrInvalidTemplate))
+	})
+}
+
+func TestRequist_PathTemplate(t *testing.T) {
+
+	var received string
+
+	// We create a Mock Server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.URL.RequestURI()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	// We create our requist Client
+	emptyClient := New(server.URL)
+
+	t.Run("send escaped segments and query", func(t *testing.T) {
+
+		_, err := emptyClient.PathTemplate("/users/{id}/repos{?type}", map[string]string{"id": "a/b", "type": "all"})
+		assert.Nil(t, err)
+
+		_, err = emptyClient.Method(http.MethodGet).Request(nil, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, "/users/a%2Fb/repos?type=all", received)
+	})
+
+	t.Run("keep path untouched on errors", func(t *testing.T) {
+
+		emptyClient.Path("/before")
+		_, err := emptyClient.PathTemplate("/users/{id}", nil)
+
+		// our data is correct?
+		assert.True(t, errors.Is(err, ErrMissingVariable))
+		assert.Equal(t, "/before", emptyClient.path)
+	})
+}
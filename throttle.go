@@ -0,0 +1,234 @@
+package requist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//=== Rate limit headers and adaptive throttling
+
+// epochThreshold separates X-RateLimit-Reset values given as epoch seconds from those given as delta seconds
+const epochThreshold = 1000000000
+
+// ErrRateLimited is returned when the host budget is exhausted for longer than Throttler.MaxWait
+var ErrRateLimited = errors.New("rate limit exhausted")
+
+// RateLimit holds the request budget advertised by a server
+type RateLimit struct {
+	// Limit is the number of requests allowed in the current window
+	Limit int
+	// Remaining is the number of requests left in the current window
+	Remaining int
+	// Reset is when the current window ends
+	Reset time.Time
+}
+
+// ParseRateLimit returns the budget found in X-RateLimit-*, RateLimit-*, RateLimit or Retry-After headers,
+// nil when there is none
+func ParseRateLimit(header http.Header, now time.Time) *RateLimit {
+
+	limit, hasLimit := headerInt(header, "RateLimit-Limit", "X-RateLimit-Limit")
+	remaining, hasRemaining := headerInt(header, "RateLimit-Remaining", "X-RateLimit-Remaining")
+	reset, hasReset := headerInt(header, "RateLimit-Reset", "X-RateLimit-Reset")
+
+	// Newer IETF drafts join them in a single structured field: limit=100, remaining=50, reset=30
+	if fields := header.Get("RateLimit"); fields != "" {
+		for _, field := range strings.Split(fields, ",") {
+			pair := strings.SplitN(strings.TrimSpace(field), "=", 2)
+			if len(pair) != 2 {
+				continue
+			}
+			value, err := strconv.Atoi(strings.Trim(pair[1], `"`))
+			if err != nil {
+				continue
+			}
+			switch strings.ToLower(pair[0]) {
+			case "limit":
+				limit, hasLimit = value, true
+			case "remaining", "r":
+				remaining, hasRemaining = value, true
+			case "reset", "t":
+				reset, hasReset = value, true
+			}
+		}
+	}
+
+	result := &RateLimit{Limit: limit, Remaining: remaining}
+	if hasReset {
+		if reset > epochThreshold {
+			result.Reset = time.Unix(int64(reset), 0)
+		} else {
+			result.Reset = now.Add(time.Duration(reset) * time.Second)
+		}
+	}
+
+	// Retry-After means nothing is left until then
+	if after := header.Get("Retry-After"); after != "" {
+		if seconds, err := strconv.Atoi(after); err == nil {
+			result.Reset = now.Add(time.Duration(seconds) * time.Second)
+		} else if date, err := http.ParseTime(after); err == nil {
+			result.Reset = date
+		}
+		if !result.Reset.IsZero() {
+			result.Remaining, hasRemaining = 0, true
+		}
+	}
+
+	if !hasLimit && !hasRemaining && !hasReset {
+		return nil
+	}
+	if !hasRemaining {
+		result.Remaining = -1
+	}
+	return result
+}
+
+// headerInt returns the first integer found in keys
+func headerInt(header http.Header, keys ...string) (int, bool) {
+
+	for _, key := range keys {
+		if value := header.Get(key); value != "" {
+			if number, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+				return number, true
+			}
+		}
+	}
+	return 0, false
+}
+
+//=== Throttler
+
+// Throttler delays requests to hosts running out of their advertised budget.
+// It is safe for concurrent use and can be shared by many Requist
+type Throttler struct {
+	// Reserve is the number of remaining requests kept untouched, requests pause until reset when reached
+	Reserve int
+	// SlowdownRatio starts spreading remaining requests until reset when remaining/limit goes under it
+	SlowdownRatio float64
+	// MaxWait is the longest pause accepted, longer ones fail with ErrRateLimited
+	MaxWait time.Duration
+
+	mutex  sync.Mutex
+	hosts  map[string]*RateLimit
+	clock  func() time.Time
+	sleep  func(ctx context.Context, delay time.Duration) error
+	issued map[string]int
+}
+
+// NewThrottler returns a Throttler slowing down under 10% of budget and waiting up to a minute
+func NewThrottler() *Throttler {
+
+	return &Throttler{
+		SlowdownRatio: 0.1,
+		MaxWait:       time.Minute,
+		hosts:         map[string]*RateLimit{},
+		issued:        map[string]int{},
+		clock:         time.Now,
+		sleep:         sleep,
+	}
+}
+
+// RateLimit returns the last budget observed for host, nil if unknown
+func (t *Throttler) RateLimit(host string) *RateLimit {
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if limit, ok := t.hosts[host]; ok {
+		copied := *limit
+		return &copied
+	}
+	return nil
+}
+
+// observe stores the budget advertised by host on response header
+func (t *Throttler) observe(host string, header http.Header) {
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if limit := ParseRateLimit(header, t.clock()); limit != nil {
+		t.hosts[host] = limit
+		t.issued[host] = 0
+	}
+}
+
+// delay returns how long a request to host must wait
+func (t *Throttler) delay(host string) (time.Duration, error) {
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	limit, ok := t.hosts[host]
+	now := t.clock()
+	if !ok || limit.Remaining < 0 || limit.Reset.IsZero() || !now.Before(limit.Reset) {
+		return 0, nil
+	}
+
+	// Requests issued since last observation also consume our budget
+	remaining := limit.Remaining - t.issued[host]
+	t.issued[host]++
+	window := limit.Reset.Sub(now)
+
+	var delay time.Duration
+	switch {
+	case remaining <= t.Reserve:
+		delay = window
+	case limit.Limit > 0 && float64(remaining)/float64(limit.Limit) < t.SlowdownRatio:
+		delay = window / time.Duration(remaining-t.Reserve+1)
+	}
+
+	if t.MaxWait > 0 && delay > t.MaxWait {
+		t.issued[host]--
+		return 0, fmt.Errorf("%w for %s until %s", ErrRateLimited, host, limit.Reset.Format(time.RFC3339))
+	}
+	return delay, nil
+}
+
+// wait pauses a request to host as needed, respecting ctx
+func (t *Throttler) wait(ctx context.Context, host string) error {
+
+	delay, err := t.delay(host)
+	if err != nil || delay <= 0 {
+		return err
+	}
+
+	Logger.Debug("Throttling Request to %s for %s", host, delay)
+	return t.sleep(ctx, delay)
+}
+
+// sleep waits for delay or until ctx is done
+func sleep(ctx context.Context, delay time.Duration) error {
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+//=== Requist integration
+
+// SetClientThrottler take throttler param and use it to pace requests by host, nil disables it
+func (r *Requist) SetClientThrottler(throttler *Throttler) {
+
+	Logger.Debug("Setting Client Throttler")
+
+	r.throttler = throttler
+}
+
+// RateLimit return the budget advertised by last response, nil if none
+func (r *Requist) RateLimit() *RateLimit {
+
+	return r.ratelimit
+}
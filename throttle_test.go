@@ -0,0 +1,135 @@
+package requist
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimit(t *testing.T) {
+
+	now := time.Unix(1600000000, 0)
+
+	t.Run("return nil without headers", func(t *testing.T) {
+
+		// our data is correct?
+		assert.Nil(t, ParseRateLimit(http.Header{}, now))
+	})
+
+	t.Run("parse X-RateLimit headers with epoch reset", func(t *testing.T) {
+
+		header := http.Header{}
+		header.Set("X-RateLimit-Limit", "5000")
+		header.Set("X-RateLimit-Remaining", "4999")
+		header.Set("X-RateLimit-Reset", "1600000060")
+
+		// our data is correct?
+		assert.EqualValues(t, &RateLimit{Limit: 5000, Remaining: 4999, Reset: now.Add(time.Minute)}, ParseRateLimit(header, now))
+	})
+
+	t.Run("parse RateLimit headers with delta reset", func(t *testing.T) {
+
+		header := http.Header{}
+		header.Set("RateLimit-Limit", "100")
+		header.Set("RateLimit-Remaining", "10")
+		header.Set("RateLimit-Reset", "30")
+
+		// our data is correct?
+		assert.EqualValues(t, &RateLimit{Limit: 100, Remaining: 10, Reset: now.Add(30 * time.Second)}, ParseRateLimit(header, now))
+	})
+
+	t.Run("parse RateLimit structured header", func(t *testing.T) {
+
+		header := http.Header{}
+		header.Set("RateLimit", "limit=100, remaining=50, reset=5")
+
+		// our data is correct?
+		assert.EqualValues(t, &RateLimit{Limit: 100, Remaining: 50, Reset: now.Add(5 * time.Second)}, ParseRateLimit(header, now))
+	})
+
+	t.Run("parse Retry-After as exhausted budget", func(t *testing.T) {
+
+		header := http.Header{}
+		header.Set("Retry-After", "120")
+
+		// our data is correct?
+		assert.EqualValues(t, &RateLimit{Remaining: 0, Reset: now.Add(2 * time.Minute)}, ParseRateLimit(header, now))
+	})
+}
+
+func TestRequist_SetClientThrottler(t *testing.T) {
+
+	remaining := 3
+
+	// We create a Mock Server advertising a budget of 100 requests, resetting in 10 seconds
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("RateLimit-Limit", "100")
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("RateLimit-Reset", "10")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	host := server.Listener.Addr().String()
+	now := time.Now()
+
+	var delays []time.Duration
+	throttler := NewThrottler()
+	throttler.clock = func() time.Time { return now }
+	throttler.sleep = func(ctx context.Context, delay time.Duration) error {
+		delays = append(delays, delay)
+		return nil
+	}
+
+	// We create our requist Client
+	emptyClient := New(server.URL)
+	emptyClient.SetClientThrottler(throttler)
+
+	t.Run("expose parsed limits", func(t *testing.T) {
+
+		_, err := emptyClient.Get("/", nil, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.EqualValues(t, 100, emptyClient.RateLimit().Limit)
+		assert.EqualValues(t, 3, emptyClient.RateLimit().Remaining)
+		assert.EqualValues(t, 3, throttler.RateLimit(host).Remaining)
+		assert.Empty(t, delays)
+	})
+
+	t.Run("slow down under slowdown ratio", func(t *testing.T) {
+
+		remaining = 0
+		_, err := emptyClient.Get("/", nil, nil)
+
+		// 3 requests left in 10 seconds, one every 2.5 seconds
+		assert.Nil(t, err)
+		assert.Len(t, delays, 1)
+		assert.EqualValues(t, 2500*time.Millisecond, delays[0])
+	})
+
+	t.Run("pause until reset when exhausted", func(t *testing.T) {
+
+		_, err := emptyClient.Get("/", nil, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Len(t, delays, 2)
+		assert.EqualValues(t, 10*time.Second, delays[1])
+	})
+
+	t.Run("fail when pause is longer than max wait", func(t *testing.T) {
+
+		throttler.MaxWait = time.Second
+		_, err := emptyClient.Get("/", nil, nil)
+
+		// our data is correct?
+		assert.True(t, errors.Is(err, ErrRateLimited))
+		assert.Len(t, delays, 2)
+	})
+}
@@ -0,0 +1,133 @@
+package requist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+//=== Per-request context and timeouts
+
+var (
+	// ErrTimeout is returned when a timeout set on requist elapsed, the Timeout of the request or the client one
+	ErrTimeout = errors.New("request timed out")
+	// ErrCanceled is returned when the context of the request was canceled or reached its own deadline
+	ErrCanceled = errors.New("request canceled")
+	// ErrServerTimeout is returned when the server was too slow connecting, handshaking or answering
+	// and the transport gave up before any requist timeout
+	ErrServerTimeout = errors.New("server timed out")
+)
+
+// RequestError describes a request that didn't complete, Reason is ErrTimeout, ErrCanceled or ErrServerTimeout
+type RequestError struct {
+	// URI of the request
+	URI string
+	// Reason why the request didn't complete
+	Reason error
+	// Err is the error returned while sending it
+	Err error
+}
+
+// Error implements error interface
+func (e *RequestError) Error() string {
+
+	return fmt.Sprintf("%s %s: %s", e.Reason, e.URI, e.Err)
+}
+
+// Unwrap allows errors.Is(err, context.Canceled) and alike on the original error
+func (e *RequestError) Unwrap() error {
+
+	return e.Err
+}
+
+// Is allows errors.Is(err, ErrTimeout) and alike on the reason
+func (e *RequestError) Is(target error) bool {
+
+	return target == e.Reason
+}
+
+// timeoutKey holds, in a context with a Timeout, the context it was derived from
+type timeoutKey struct{}
+
+// withTimeout returns parent with a deadline timeout from now, remembering who set it
+func withTimeout(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	return context.WithValue(ctx, timeoutKey{}, parent), cancel
+}
+
+// isTimeout tells if err is a network timeout
+func isTimeout(err error) bool {
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// abortError classifies err, returned while sending a request to uri with ctx, as a RequestError if it's one
+func abortError(ctx context.Context, uri string, err error) error {
+
+	var requestErr *RequestError
+	if errors.As(err, &requestErr) {
+		return err
+	}
+
+	var reason error
+	switch {
+	case ctx.Err() != nil:
+		// A Timeout elapsed if its parent context is still alive, otherwise the caller gave up
+		reason = ErrCanceled
+		for c := ctx; ; {
+			parent, ok := c.Value(timeoutKey{}).(context.Context)
+			if !ok {
+				break
+			}
+			if parent.Err() == nil {
+				reason = ErrTimeout
+				break
+			}
+			c = parent
+		}
+	case isTimeout(err):
+		reason = ErrServerTimeout
+	default:
+		return err
+	}
+	return &RequestError{URI: uri, Reason: reason, Err: err}
+}
+
+//=== Requist integration
+
+// WithContext sets the context of next request only, instead of the one set by SetClientContext
+func (r *Requist) WithContext(ctx context.Context) *Requist {
+
+	r.requestCtx = ctx
+
+	return r
+}
+
+// Timeout sets how long next request may take, covering every attempt, failovers and hedges included,
+// and waits on limiters. SetClientTimeout still bounds every single attempt
+func (r *Requist) Timeout(timeout time.Duration) *Requist {
+
+	r.timeout = timeout
+
+	return r
+}
+
+// requestContext returns the context of next request, with its Timeout if any, and resets them
+func (r *Requist) requestContext() (context.Context, context.CancelFunc) {
+
+	ctx := r.ctx
+	if r.requestCtx != nil {
+		ctx = r.requestCtx
+	}
+	timeout := r.timeout
+	r.requestCtx, r.timeout = nil, 0
+
+	if timeout > 0 {
+		return withTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
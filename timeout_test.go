@@ -0,0 +1,163 @@
+package requist
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// MockSlowServer answers after delay, counting requests
+func MockSlowServer(delay time.Duration, requests *int32) *httptest.Server {
+
+	return httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(requests, 1)
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}),
+	)
+}
+
+func TestRequist_Timeout(t *testing.T) {
+
+	var requests int32
+
+	// We create a Mock Server
+	server := MockSlowServer(200*time.Millisecond, &requests)
+	defer server.Close()
+
+	t.Run("time out next request only", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+
+		_, err := emptyClient.Timeout(50*time.Millisecond).Get("/slow", nil, nil)
+
+		// our data is correct?
+		var requestErr *RequestError
+		assert.True(t, errors.Is(err, ErrTimeout))
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.True(t, errors.As(err, &requestErr))
+		assert.Equal(t, server.URL+"/slow", requestErr.URI)
+
+		_, err = emptyClient.Get("/slow", nil, nil)
+		assert.Nil(t, err)
+	})
+
+	t.Run("time out with client timeout", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.SetClientTimeout(50 * time.Millisecond)
+
+		_, err := emptyClient.Get("/slow", nil, nil)
+
+		// our data is correct?
+		assert.True(t, errors.Is(err, ErrTimeout))
+	})
+
+	t.Run("tell server slowness apart", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.transport().ResponseHeaderTimeout = 50 * time.Millisecond
+
+		_, err := emptyClient.Timeout(time.Second).Get("/slow", nil, nil)
+
+		// our data is correct?
+		assert.True(t, errors.Is(err, ErrServerTimeout))
+		assert.False(t, errors.Is(err, ErrTimeout))
+	})
+
+	t.Run("keep deadline across failover", func(t *testing.T) {
+
+		balancer, err := NewBalancer(RoundRobin, server.URL, server.URL)
+		assert.Nil(t, err)
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.SetClientBalancer(balancer)
+
+		atomic.StoreInt32(&requests, 0)
+		started := time.Now()
+		_, err = emptyClient.Timeout(50*time.Millisecond).Get("/slow", nil, nil)
+
+		// our data is correct?
+		assert.True(t, errors.Is(err, ErrTimeout))
+		assert.True(t, time.Since(started) < 200*time.Millisecond)
+		assert.EqualValues(t, 1, atomic.LoadInt32(&requests))
+	})
+}
+
+func TestRequist_WithContext(t *testing.T) {
+
+	var requests int32
+
+	// We create a Mock Server
+	server := MockSlowServer(200*time.Millisecond, &requests)
+	defer server.Close()
+
+	t.Run("cancel next request only", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		_, err := emptyClient.WithContext(ctx).Get("/slow", nil, nil)
+
+		// our data is correct?
+		assert.True(t, errors.Is(err, ErrCanceled))
+		assert.True(t, errors.Is(err, context.Canceled))
+
+		_, err = emptyClient.Get("/slow", nil, nil)
+		assert.Nil(t, err)
+	})
+
+	t.Run("tell caller deadline apart from Timeout", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := emptyClient.WithContext(ctx).Timeout(time.Second).Get("/slow", nil, nil)
+
+		// our data is correct?
+		assert.True(t, errors.Is(err, ErrCanceled))
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+
+	t.Run("cover every attempt of Update", func(t *testing.T) {
+
+		resource := &mockResource{value: "first"}
+
+		// We create a Mock Server
+		server := MockResourceServer(resource)
+		defer server.Close()
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.Accept(JSONContentType)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		_, err := emptyClient.WithContext(ctx).Update(http.MethodPut, "/resource", &GenericResponse{}, func() error {
+			cancel()
+			return nil
+		}, 3)
+
+		// our data is correct?
+		assert.True(t, errors.Is(err, ErrCanceled))
+		assert.Equal(t, "first", resource.value)
+	})
+}
@@ -0,0 +1,237 @@
+package requist
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+//=== TLS configuration helpers
+
+// ErrInvalidCertificates is returned when a PEM bundle does not contain any certificate
+var ErrInvalidCertificates = errors.New("no valid certificates found in PEM")
+
+// tlsConfig returns the client TLS configuration, creating one if missing
+func (r *Requist) tlsConfig() *tls.Config {
+
+	transport := r.transport()
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	return transport.TLSClientConfig
+}
+
+// SetClientTLSConfig take config param and set client TLS configuration, public key pins set are kept
+// on a copy of it
+func (r *Requist) SetClientTLSConfig(config *tls.Config) {
+
+	Logger.Debug("Setting Client TLS Config")
+
+	if r.pins != nil {
+		if config == nil {
+			config = &tls.Config{}
+		} else {
+			config = config.Clone()
+		}
+		config.VerifyConnection = verifyPins(r.pins, config.VerifyConnection)
+	}
+	r.transport().TLSClientConfig = config
+}
+
+// SetClientRootCAs trust only the certificates found in PEM bundles to verify servers
+func (r *Requist) SetClientRootCAs(bundles ...[]byte) error {
+
+	pool := x509.NewCertPool()
+	for _, bundle := range bundles {
+		if !pool.AppendCertsFromPEM(bundle) {
+			return ErrInvalidCertificates
+		}
+	}
+
+	Logger.Debug("Setting Client Root CAs from %d bundles", len(bundles))
+
+	r.tlsConfig().RootCAs = pool
+	return nil
+}
+
+// SetClientRootCAsFromFiles trust only the certificates found in PEM files to verify servers
+func (r *Requist) SetClientRootCAsFromFiles(filenames ...string) error {
+
+	bundles := make([][]byte, 0, len(filenames))
+	for _, filename := range filenames {
+		bundle, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		bundles = append(bundles, bundle)
+	}
+	return r.SetClientRootCAs(bundles...)
+}
+
+// SetClientCertificate set the client certificate presented for mutual TLS from PEM blocks
+func (r *Requist) SetClientCertificate(certPEM, keyPEM []byte) error {
+
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return err
+	}
+
+	Logger.Debug("Setting Client Certificate")
+
+	config := r.tlsConfig()
+	config.Certificates = []tls.Certificate{certificate}
+	config.GetClientCertificate = nil
+	return nil
+}
+
+// SetClientCertificateFromFiles set the client certificate presented for mutual TLS from PEM files
+func (r *Requist) SetClientCertificateFromFiles(certFile, keyFile string) error {
+
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+
+	Logger.Debug("Setting Client Certificate from %s", certFile)
+
+	config := r.tlsConfig()
+	config.Certificates = []tls.Certificate{certificate}
+	config.GetClientCertificate = nil
+	return nil
+}
+
+// SetClientCertificateReloader set the client certificate presented for mutual TLS from a reloader
+func (r *Requist) SetClientCertificateReloader(reloader *CertificateReloader) {
+
+	Logger.Debug("Setting Client Certificate Reloader")
+
+	config := r.tlsConfig()
+	config.Certificates = nil
+	config.GetClientCertificate = nil
+	if reloader != nil {
+		config.GetClientCertificate = reloader.GetClientCertificate
+	}
+}
+
+// SetClientTLSMinVersion take version param (ie: tls.VersionTLS12) and set minimum TLS version accepted
+func (r *Requist) SetClientTLSMinVersion(version uint16) {
+
+	Logger.Debug("Setting Client TLS MinVersion %x", version)
+
+	r.tlsConfig().MinVersion = version
+}
+
+// SetClientTLSCipherSuites take suites param and set the cipher suites enabled up to TLS 1.2
+func (r *Requist) SetClientTLSCipherSuites(suites ...uint16) {
+
+	Logger.Debug("Setting Client TLS CipherSuites %v", suites)
+
+	r.tlsConfig().CipherSuites = suites
+}
+
+// SetClientTLSServerName take name param and set the server name sent with SNI and verified on certificates
+func (r *Requist) SetClientTLSServerName(name string) {
+
+	Logger.Debug("Setting Client TLS ServerName %s", name)
+
+	r.tlsConfig().ServerName = name
+}
+
+//=== CertificateReloader, keeps client certificates up to date when rotated on disk
+
+// CertificateReloader loads a certificate/key pair and reloads it when files change
+type CertificateReloader struct {
+	mutex       sync.RWMutex
+	certFile    string
+	keyFile     string
+	interval    time.Duration
+	checked     time.Time
+	modified    time.Time
+	certificate *tls.Certificate
+}
+
+// NewCertificateReloader loads certFile and keyFile, checking for changes at most every interval
+func NewCertificateReloader(certFile, keyFile string, interval time.Duration) (*CertificateReloader, error) {
+
+	reloader := &CertificateReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		interval: interval,
+	}
+	if err := reloader.Reload(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+// Reload reads again the certificate/key pair from disk
+func (c *CertificateReloader) Reload() error {
+
+	modified, err := c.lastModified()
+	if err != nil {
+		return err
+	}
+
+	certificate, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	c.certificate = &certificate
+	c.modified = modified
+	c.checked = time.Now()
+	c.mutex.Unlock()
+
+	Logger.Debug("Loaded Client Certificate from %s", c.certFile)
+	return nil
+}
+
+// GetClientCertificate implements tls.Config GetClientCertificate, reloading files if rotated
+func (c *CertificateReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+
+	c.mutex.RLock()
+	certificate, checked, modified := c.certificate, c.checked, c.modified
+	c.mutex.RUnlock()
+
+	if time.Since(checked) < c.interval {
+		return certificate, nil
+	}
+
+	current, err := c.lastModified()
+	if err == nil && current.After(modified) {
+		err = c.Reload()
+	}
+	if err != nil {
+		// Keep serving the previous certificate while files are being rotated
+		Logger.Error("Unable to reload Client Certificate from %s: %s", c.certFile, err)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.checked = time.Now()
+	return c.certificate, nil
+}
+
+// lastModified returns the newest modification time between certificate and key files
+func (c *CertificateReloader) lastModified() (time.Time, error) {
+
+	certInfo, err := os.Stat(c.certFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+	keyInfo, err := os.Stat(c.keyFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if keyInfo.ModTime().After(certInfo.ModTime()) {
+		return keyInfo.ModTime(), nil
+	}
+	return certInfo.ModTime(), nil
+}
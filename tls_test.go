@@ -0,0 +1,312 @@
+package requist
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// mockPKI is a throwaway certificate authority used to issue test certificates
+type mockPKI struct {
+	t     *testing.T
+	ca    *x509.Certificate
+	key   *ecdsa.PrivateKey
+	CAPEM []byte
+}
+
+// newMockPKI creates a self signed certificate authority
+func newMockPKI(t *testing.T) *mockPKI {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Requist Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+
+	ca, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+
+	return &mockPKI{
+		t:     t,
+		ca:    ca,
+		key:   key,
+		CAPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+// issue returns a certificate/key pair in PEM format signed by our CA
+func (p *mockPKI) issue(commonName string, hosts ...string) (certPEM, keyPEM []byte) {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(p.t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, p.ca, &key.PublicKey, p.key)
+	assert.Nil(p.t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(p.t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// MockTLSServer starts a TLS server with a certificate issued for hosts, answering with the client certificate CN
+func MockTLSServer(pki *mockPKI, clientAuth tls.ClientAuthType, hosts ...string) *httptest.Server {
+
+	certPEM, keyPEM := pki.issue("server", hosts...)
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	assert.Nil(pki.t, err)
+
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(pki.CAPEM)
+
+	server := httptest.NewUnstartedServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result := ""
+			if len(r.TLS.PeerCertificates) > 0 {
+				result = r.TLS.PeerCertificates[0].Subject.CommonName
+			}
+			w.Header().Set("Content-Type", JSONContentType)
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"result": "` + result + `"}`))
+		}),
+	)
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		ClientAuth:   clientAuth,
+		ClientCAs:    pool,
+	}
+	server.StartTLS()
+
+	return server
+}
+
+func TestRequist_SetClientRootCAs(t *testing.T) {
+
+	pki := newMockPKI(t)
+
+	// We create a Mock Server
+	server := MockTLSServer(pki, tls.NoClientCert, "127.0.0.1")
+	defer server.Close()
+
+	t.Run("fail with unknown authority", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+
+		_, err := emptyClient.Get("/", nil, nil)
+
+		// if client return Nil?
+		assert.NotNil(t, err)
+	})
+
+	t.Run("fail with invalid PEM", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+
+		// our data is correct?
+		assert.Equal(t, ErrInvalidCertificates, emptyClient.SetClientRootCAs([]byte("garbage")))
+	})
+
+	t.Run("success with our authority", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		assert.Nil(t, emptyClient.SetClientRootCAs(pki.CAPEM))
+
+		_, err := emptyClient.Get("/", nil, nil)
+
+		// if client return not Nil?
+		assert.Nil(t, err)
+		assert.EqualValues(t, http.StatusOK, emptyClient.StatusCode())
+	})
+}
+
+func TestRequist_SetClientCertificate(t *testing.T) {
+
+	pki := newMockPKI(t)
+
+	// We create a Mock Server
+	server := MockTLSServer(pki, tls.RequireAndVerifyClientCert, "127.0.0.1")
+	defer server.Close()
+
+	t.Run("fail without client certificate", func(t *testing.T) {
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		assert.Nil(t, emptyClient.SetClientRootCAs(pki.CAPEM))
+
+		_, err := emptyClient.Get("/", nil, nil)
+
+		// if client return Nil?
+		assert.NotNil(t, err)
+	})
+
+	t.Run("success with client certificate", func(t *testing.T) {
+
+		success := &GenericResponse{}
+		certPEM, keyPEM := pki.issue("jonah")
+
+		// We create our requist Client
+		emptyClient := New(server.URL)
+		emptyClient.Accept(JSONContentType)
+		assert.Nil(t, emptyClient.SetClientRootCAs(pki.CAPEM))
+		assert.Nil(t, emptyClient.SetClientCertificate(certPEM, keyPEM))
+
+		_, err := emptyClient.Get("/", success, nil)
+
+		// if client return not Nil?
+		assert.Nil(t, err)
+
+		// our data is correct?
+		assert.EqualValues(t, "jonah", success.Result)
+	})
+}
+
+func TestCertificateReloader(t *testing.T) {
+
+	pki := newMockPKI(t)
+
+	// We create a Mock Server
+	server := MockTLSServer(pki, tls.RequireAndVerifyClientCert, "127.0.0.1")
+	defer server.Close()
+
+	// We create a temporary place to store our files
+	dir, err := ioutil.TempDir("", "requist")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	caFile := filepath.Join(dir, "ca.pem")
+	certFile := filepath.Join(dir, "client.pem")
+	keyFile := filepath.Join(dir, "client.key")
+
+	rotate := func(commonName string, modified time.Time) {
+		certPEM, keyPEM := pki.issue(commonName)
+		assert.Nil(t, ioutil.WriteFile(certFile, certPEM, 0600))
+		assert.Nil(t, ioutil.WriteFile(keyFile, keyPEM, 0600))
+		assert.Nil(t, os.Chtimes(certFile, modified, modified))
+		assert.Nil(t, os.Chtimes(keyFile, modified, modified))
+	}
+	assert.Nil(t, ioutil.WriteFile(caFile, pki.CAPEM, 0600))
+	rotate("jonah", time.Now().Add(-time.Minute))
+
+	reloader, err := NewCertificateReloader(certFile, keyFile, 0)
+	assert.Nil(t, err)
+
+	// We create our requist Client
+	emptyClient := New(server.URL)
+	emptyClient.Accept(JSONContentType)
+	assert.Nil(t, emptyClient.SetClientRootCAsFromFiles(caFile))
+	emptyClient.SetClientCertificateReloader(reloader)
+
+	t.Run("present current certificate", func(t *testing.T) {
+
+		success := &GenericResponse{}
+		_, err := emptyClient.Get("/", success, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.EqualValues(t, "jonah", success.Result)
+	})
+
+	t.Run("present rotated certificate", func(t *testing.T) {
+
+		rotate("jason", time.Now())
+
+		// Force a new handshake
+		emptyClient.transport().CloseIdleConnections()
+
+		success := &GenericResponse{}
+		_, err := emptyClient.Get("/", success, nil)
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.EqualValues(t, "jason", success.Result)
+	})
+}
+
+func TestRequist_SetClientTLSMinVersion(t *testing.T) {
+
+	pki := newMockPKI(t)
+
+	// We create a Mock Server limited to TLS 1.2
+	server := MockTLSServer(pki, tls.NoClientCert, "127.0.0.1")
+	server.TLS.MaxVersion = tls.VersionTLS12
+	defer server.Close()
+
+	// We create our requist Client
+	emptyClient := New(server.URL)
+	assert.Nil(t, emptyClient.SetClientRootCAs(pki.CAPEM))
+	emptyClient.SetClientTLSMinVersion(tls.VersionTLS13)
+
+	// was modified out Client?
+	assert.EqualValues(t, tls.VersionTLS13, emptyClient.tlsConfig().MinVersion)
+
+	_, err := emptyClient.Get("/", nil, nil)
+
+	// if client return Nil?
+	assert.NotNil(t, err)
+}
+
+func TestRequist_SetClientTLSServerName(t *testing.T) {
+
+	pki := newMockPKI(t)
+
+	// We create a Mock Server with a certificate valid only for api.internal
+	server := MockTLSServer(pki, tls.NoClientCert, "api.internal")
+	defer server.Close()
+
+	// We create our requist Client
+	emptyClient := New(server.URL)
+	assert.Nil(t, emptyClient.SetClientRootCAs(pki.CAPEM))
+
+	_, err := emptyClient.Get("/", nil, nil)
+
+	// if client return Nil?
+	assert.NotNil(t, err)
+
+	emptyClient.SetClientTLSServerName("api.internal")
+	_, err = emptyClient.Get("/", nil, nil)
+
+	// if client return not Nil?
+	assert.Nil(t, err)
+}
@@ -0,0 +1,146 @@
+package requist
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+//=== Typed requests
+
+// Response describes what the server answered to a typed request
+type Response struct {
+	// StatusCode and Header of the response
+	StatusCode int
+	Header     http.Header
+	// Redirects followed, budget advertised and how the response was obtained from the cache
+	Redirects   []string
+	RateLimit   *RateLimit
+	CacheStatus CacheStatus
+	// Validators of the response
+	ETag         string
+	LastModified time.Time
+}
+
+// StatusError is returned by typed requests answered with a non 2xx status, Body holds the response body
+// decoded as E when possible and Raw the body as received
+type StatusError[E any] struct {
+	StatusCode int
+	Body       E
+	Raw        []byte
+}
+
+// Error implements error interface
+func (e *StatusError[E]) Error() string {
+
+	return fmt.Sprintf("server answered %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// rawBody receives a response body as is
+type rawBody []byte
+
+// capturedResponse decodes with BodyResponse, except for rawBody values which receive the body as is
+type capturedResponse struct {
+	BodyResponse
+}
+
+// Decode decodes the Response Body into the value pointed to by v
+func (c capturedResponse) Decode(resp io.Reader, v interface{}) (err error) {
+
+	if raw, ok := v.(*rawBody); ok {
+		*raw, err = ioutil.ReadAll(resp)
+		return err
+	}
+	return c.BodyResponse.Decode(resp, v)
+}
+
+// Do sends a method request to path with client and ctx, nil to use the client one, decoding a 2xx response
+// body as T. Other responses return a *StatusError[E]. body, if not nil, is sent as is when it's a BodyProvider
+// or as JSON otherwise. Bodies are decoded with the BodyResponse set by Accept, JSON if none.
+// The Response is nil when no response was received
+func Do[T, E any](ctx context.Context, client *Requist, method, path string, body interface{}) (T, *Response, error) {
+
+	var success T
+	var failure rawBody
+
+	if ctx != nil {
+		client.WithContext(ctx)
+	}
+	switch provider := body.(type) {
+	case nil:
+	case BodyProvider:
+		client.BodyProvider(provider)
+	default:
+		client.BodyAsJSON(provider)
+	}
+
+	// Failures are kept raw, so a body not matching E doesn't hide the status
+	original := client.response
+	decoder := original
+	if decoder == nil {
+		decoder = jsonResponse{}
+		client.SetRequestHeader(acceptHeader, JSONContentType)
+	}
+	client.response = capturedResponse{BodyResponse: decoder}
+	_, err := client.Method(method).Path(path).Request(&success, &failure)
+	client.response = original
+
+	if client.responseHeader == nil {
+		return success, nil, err
+	}
+	response := &Response{
+		StatusCode:   client.statuscode,
+		Header:       client.responseHeader,
+		Redirects:    client.redirects,
+		RateLimit:    client.ratelimit,
+		CacheStatus:  client.cacheStatus,
+		ETag:         client.etag,
+		LastModified: client.lastModified,
+	}
+	if err != nil {
+		return success, response, err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		statusErr := &StatusError[E]{StatusCode: response.StatusCode, Raw: failure}
+		if len(failure) > 0 {
+			_ = decoder.Decode(bytes.NewReader(failure), &statusErr.Body)
+		}
+		return success, response, statusErr
+	}
+	return success, response, nil
+}
+
+// Get sends a GET request to path decoding the response as T, failures return a *StatusError[any]
+func Get[T any](ctx context.Context, client *Requist, path string) (T, *Response, error) {
+
+	return Do[T, any](ctx, client, http.MethodGet, path, nil)
+}
+
+// Post sends a POST request to path with body decoding the response as T, failures return a *StatusError[any]
+func Post[T any](ctx context.Context, client *Requist, path string, body interface{}) (T, *Response, error) {
+
+	return Do[T, any](ctx, client, http.MethodPost, path, body)
+}
+
+// Put sends a PUT request to path with body decoding the response as T, failures return a *StatusError[any]
+func Put[T any](ctx context.Context, client *Requist, path string, body interface{}) (T, *Response, error) {
+
+	return Do[T, any](ctx, client, http.MethodPut, path, body)
+}
+
+// Patch sends a PATCH request to path with body decoding the response as T, failures return a *StatusError[any]
+func Patch[T any](ctx context.Context, client *Requist, path string, body interface{}) (T, *Response, error) {
+
+	return Do[T, any](ctx, client, http.MethodPatch, path, body)
+}
+
+// Delete sends a DELETE request to path decoding the response as T, failures return a *StatusError[any]
+func Delete[T any](ctx context.Context, client *Requist, path string) (T, *Response, error) {
+
+	return Do[T, any](ctx, client, http.MethodDelete, path, nil)
+}
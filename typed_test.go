@@ -0,0 +1,136 @@
+package requist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// APIError is the failure body answered by MockTypedServer
+type APIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// MockTypedServer serves users as JSON, answering JSON or HTML failures
+func MockTypedServer() *httptest.Server {
+
+	return httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/users/1":
+				w.Header().Set("Content-Type", JSONContentType)
+				w.Header().Set("ETag", `"v1"`)
+				if r.Method == http.MethodPut {
+					user := &UserInfo{}
+					_ = json.NewDecoder(r.Body).Decode(user)
+					user.Age++
+					_ = json.NewEncoder(w).Encode(user)
+					return
+				}
+				_, _ = w.Write([]byte(`{"name": "Jonah Doe", "age": 50}`))
+			case "/users/2":
+				w.Header().Set("Content-Type", JSONContentType)
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"code": "not_found", "message": "no such user"}`))
+			default:
+				w.Header().Set("Content-Type", "text/html")
+				w.WriteHeader(http.StatusBadGateway)
+				_, _ = w.Write([]byte(`<html>Bad Gateway</html>`))
+			}
+		}),
+	)
+}
+
+func TestGet(t *testing.T) {
+
+	// We create a Mock Server
+	server := MockTypedServer()
+	defer server.Close()
+
+	// We create our requist Client
+	emptyClient := New(server.URL)
+
+	t.Run("decode success as T", func(t *testing.T) {
+
+		user, response, err := Get[UserInfo](context.Background(), emptyClient, "/users/1")
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, UserInfo{Name: "Jonah Doe", Age: 50}, user)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, JSONContentType, response.Header.Get("Content-Type"))
+		assert.Equal(t, `"v1"`, response.ETag)
+		assert.Nil(t, emptyClient.response)
+	})
+
+	t.Run("return failures as StatusError", func(t *testing.T) {
+
+		_, response, err := Get[UserInfo](context.Background(), emptyClient, "/users/2")
+
+		// our data is correct?
+		var statusErr *StatusError[any]
+		assert.True(t, errors.As(err, &statusErr))
+		assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+		assert.Equal(t, map[string]interface{}{"code": "not_found", "message": "no such user"}, statusErr.Body)
+		assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	})
+
+	t.Run("return nil Response without one", func(t *testing.T) {
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, response, err := Get[UserInfo](ctx, emptyClient, "/users/1")
+
+		// our data is correct?
+		assert.True(t, errors.Is(err, ErrCanceled))
+		assert.Nil(t, response)
+	})
+}
+
+func TestDo(t *testing.T) {
+
+	// We create a Mock Server
+	server := MockTypedServer()
+	defer server.Close()
+
+	// We create our requist Client
+	emptyClient := New(server.URL)
+
+	t.Run("send body as JSON", func(t *testing.T) {
+
+		user, _, err := Put[UserInfo](context.Background(), emptyClient, "/users/1", &UserInfo{Name: "Jason Borne", Age: 47})
+
+		// our data is correct?
+		assert.Nil(t, err)
+		assert.Equal(t, UserInfo{Name: "Jason Borne", Age: 48}, user)
+	})
+
+	t.Run("decode typed failures", func(t *testing.T) {
+
+		_, _, err := Do[UserInfo, APIError](context.Background(), emptyClient, http.MethodGet, "/users/2", nil)
+
+		// our data is correct?
+		var statusErr *StatusError[APIError]
+		assert.True(t, errors.As(err, &statusErr))
+		assert.Equal(t, APIError{Code: "not_found", Message: "no such user"}, statusErr.Body)
+	})
+
+	t.Run("keep failures not matching E raw", func(t *testing.T) {
+
+		_, response, err := Do[UserInfo, APIError](context.Background(), emptyClient, http.MethodGet, "/proxy", nil)
+
+		// our data is correct?
+		var statusErr *StatusError[APIError]
+		assert.True(t, errors.As(err, &statusErr))
+		assert.Equal(t, http.StatusBadGateway, statusErr.StatusCode)
+		assert.Equal(t, APIError{}, statusErr.Body)
+		assert.Equal(t, "<html>Bad Gateway</html>", string(statusErr.Raw))
+		assert.Equal(t, http.StatusBadGateway, response.StatusCode)
+	})
+}
@@ -0,0 +1,30 @@
+package requist
+
+import (
+	"context"
+	"github.com/hashicorp/go-cleanhttp"
+	"net"
+)
+
+//=== Unix domain sockets transport
+
+// SetClientUnixSocket take socket param and dial every connection to that unix socket path,
+// an empty socket restores TCP connections
+func (r *Requist) SetClientUnixSocket(socket string) {
+
+	Logger.Debug("Setting Client Unix Socket %s", socket)
+
+	r.socket = socket
+	if socket == "" {
+		r.transport().DialContext = cleanhttp.DefaultTransport().DialContext
+		if r.dialer != nil {
+			r.transport().DialContext = r.dialer.DialContext
+		}
+		return
+	}
+
+	dialer := &net.Dialer{}
+	r.transport().DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "unix", socket)
+	}
+}
@@ -0,0 +1,124 @@
+package requist
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// MockUnixServer serves MockHTTPServer handler on a unix socket
+func MockUnixServer(t *testing.T) (string, func()) {
+
+	dir, err := ioutil.TempDir("", "requist")
+	assert.Nil(t, err)
+
+	socket := filepath.Join(dir, "api.sock")
+	listener, err := net.Listen("unix", socket)
+	assert.Nil(t, err)
+
+	mock := MockHTTPServer()
+	go func() { _ = http.Serve(listener, mock.Config.Handler) }()
+
+	return socket, func() {
+		_ = listener.Close()
+		mock.Close()
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func TestRequist_SetClientUnixSocket(t *testing.T) {
+
+	// We create a Mock Server listening on a unix socket
+	socket, closer := MockUnixServer(t)
+	defer closer()
+
+	t.Run("return one resource via unix:// base", func(t *testing.T) {
+
+		success := &UserInfo{}
+
+		// We create our requist Client
+		emptyClient := New("unix://" + socket)
+
+		// was modified out Client?
+		assert.NotNil(t, emptyClient)
+		assert.EqualValues(t, socket, emptyClient.socket)
+		emptyClient.Accept(JSONContentType)
+
+		_, err := emptyClient.Get("/user/1000", success, nil)
+
+		// if client return not Nil?
+		assert.Nil(t, err)
+
+		// our data is correct?
+		assert.EqualValues(t, "Jonah Doe", success.Name)
+	})
+
+	t.Run("return one resource via http+unix:// base", func(t *testing.T) {
+
+		success := &UserInfo{}
+
+		// We create our requist Client
+		emptyClient := New("http+unix://" + url.PathEscape(socket))
+
+		// was modified out Client?
+		assert.NotNil(t, emptyClient)
+		emptyClient.Accept(JSONContentType)
+
+		_, err := emptyClient.Get("/user/1000", success, nil)
+
+		// if client return not Nil?
+		assert.Nil(t, err)
+
+		// our data is correct?
+		assert.EqualValues(t, "Jonah Doe", success.Name)
+	})
+
+	t.Run("return one resource via http+unix:// base with path prefix", func(t *testing.T) {
+
+		success := &UserInfo{}
+
+		// We create our requist Client
+		emptyClient := New("http+unix://" + url.PathEscape(socket) + "/user")
+
+		// was modified out Client?
+		assert.NotNil(t, emptyClient)
+		assert.EqualValues(t, unixBaseURL+"/user", emptyClient.url)
+		emptyClient.Accept(JSONContentType)
+
+		_, err := emptyClient.Get("/1000", success, nil)
+
+		// if client return not Nil?
+		assert.Nil(t, err)
+
+		// our data is correct?
+		assert.EqualValues(t, "Jonah Doe", success.Name)
+	})
+
+	t.Run("return to TCP when base changes", func(t *testing.T) {
+
+		// We create a Mock Server
+		server := MockHTTPServer()
+		defer server.Close()
+
+		success := &UserInfo{}
+
+		// We create our requist Client
+		emptyClient := New("unix://" + socket)
+		emptyClient.Base(server.URL)
+		emptyClient.Accept(JSONContentType)
+
+		_, err := emptyClient.Get("/user/1000", success, nil)
+
+		// if client return not Nil?
+		assert.Nil(t, err)
+
+		// our data is correct?
+		assert.Empty(t, emptyClient.socket)
+		assert.EqualValues(t, "Jonah Doe", success.Name)
+	})
+}
@@ -1,7 +1,10 @@
 package requist
 
 import (
+	"io/ioutil"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -30,7 +33,7 @@ func IsValidBase(base string) bool {
 	return true
 }
 
-// ParseBaseURL check if is valid the base string passed
+// ParseBaseURL check if is valid the base string passed, keeping its path as prefix of every request
 func ParseBaseURL(base string) string {
 
 	urlParsed, err := url.Parse(base)
@@ -38,20 +41,92 @@ func ParseBaseURL(base string) string {
 		return ""
 	}
 	urlParsed.RawQuery = ""
+	urlParsed.ForceQuery = false
 	urlParsed.Fragment = ""
-	urlParsed.Path = ""
 	urlParsed.Opaque = ""
 
 	return urlParsed.String()
 }
 
-// ParsePathURL check relative path
+// ParsePathURL resolves path against base (RFC 3986 section 5.2), returning it escaped so encoded
+// slashes (%2F) are kept. The base path is taken as a directory, so it prefixes relative paths and,
+// as every API client expects, absolute ones too (ie: /users on https://host/api/v2 is /api/v2/users).
+// Dot segments are removed, so ../ can leave the prefix. An empty path is the base path itself
 func ParsePathURL(base string, path string) string {
 
-	urlParsed, err := url.Parse(base + path)
-	if err != nil || !IsValidScheme(urlParsed.Scheme) || !IsValidHostname(urlParsed.Host) {
+	baseParsed, err := url.Parse(base)
+	if err != nil || !IsValidScheme(baseParsed.Scheme) || !IsValidHostname(baseParsed.Host) {
+		return ""
+	}
+
+	reference, err := url.Parse(path)
+	if err != nil || reference.Scheme != "" || reference.Host != "" || reference.Opaque != "" {
+		return ""
+	}
+
+	prefix := baseParsed.EscapedPath()
+	escaped := reference.EscapedPath()
+	if escaped == "" {
+		return prefix
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	if strings.HasPrefix(escaped, "/") {
+		escaped = "." + escaped
+	}
+
+	directory, err := url.Parse(prefix)
+	if err != nil {
 		return ""
 	}
+	relative, err := url.Parse(escaped)
+	if err != nil {
+		return ""
+	}
+	return directory.ResolveReference(relative).EscapedPath()
+}
 
+// ParseUnixSocket returns the socket path of a unix:///path/to.sock or http+unix://%2Fpath%2Fto.sock base
+func ParseUnixSocket(base string) string {
+
+	// url.Parse rejects percent-encoded hosts, so http+unix authority is handled by hand
+	if strings.HasPrefix(base, unixHTTPScheme) {
+		host := strings.TrimPrefix(base, unixHTTPScheme)
+		if i := strings.IndexAny(host, "/?#"); i >= 0 {
+			host = host[:i]
+		}
+		socket, err := url.PathUnescape(host)
+		if err != nil {
+			return ""
+		}
+		return socket
+	}
+
+	urlParsed, err := url.Parse(base)
+	if err != nil || urlParsed.Scheme != "unix" || urlParsed.Host != "" {
+		return ""
+	}
 	return urlParsed.Path
 }
+
+//=== Supplemental functions to handle files
+
+// writeFile writes content to a temporary file first and renames it, so a crash never leaves filename truncated
+func writeFile(filename string, content []byte) error {
+
+	temp, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err = temp.Write(content); err != nil {
+		_ = temp.Close()
+		_ = os.Remove(temp.Name())
+		return err
+	}
+	if err = temp.Close(); err != nil {
+		_ = os.Remove(temp.Name())
+		return err
+	}
+	return os.Rename(temp.Name(), filename)
+}
@@ -199,8 +199,8 @@ func TestParseBaseURL(t *testing.T) {
 
 	t.Run("return same if a valid baseURL", func(t *testing.T) {
 		// Define some vars
-		var baseURL = "https://live.apitest.org/path/to/resource"
-		var expected = "https://live.apitest.org"
+		var baseURL = "https://live.apitest.org/path/to/resource?query=1#fragment"
+		var expected = "https://live.apitest.org/path/to/resource"
 
 		// fire up
 		// We create our requist Client
@@ -255,3 +255,87 @@ func TestWithPathURL(t *testing.T) {
 		assert.Equal(t, expected, result)
 	})
 }
+
+func TestParsePathURL_Resolution(t *testing.T) {
+
+	// How request paths resolve against bases with and without path prefix
+	tests := []struct {
+		base     string
+		path     string
+		expected string
+	}{
+		{"https://host", "", ""},
+		{"https://host", "/users", "/users"},
+		{"https://host", "users", "/users"},
+		{"https://host/", "/users", "/users"},
+		{"https://host/api/v2", "", "/api/v2"},
+		{"https://host/api/v2/", "", "/api/v2/"},
+		{"https://host/api/v2", "/users", "/api/v2/users"},
+		{"https://host/api/v2", "users", "/api/v2/users"},
+		{"https://host/api/v2/", "/users", "/api/v2/users"},
+		{"https://host/api/v2/", "users", "/api/v2/users"},
+		{"https://host/api/v2", "/users/", "/api/v2/users/"},
+		{"https://host/api/v2", "./users", "/api/v2/users"},
+		{"https://host/api/v2", "../v1/users", "/api/v1/users"},
+		{"https://host/api/v2", "/users/./42/../43", "/api/v2/users/43"},
+		{"https://host/api/v2", "/users?active=true", "/api/v2/users"},
+		{"https://host/api/v2", "/users/a%2Fb", "/api/v2/users/a%2Fb"},
+		{"https://host/api%2Fv2", "/users", "/api%2Fv2/users"},
+		{"https://host/api/v2", "https://other/users", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.base+" + "+test.path, func(t *testing.T) {
+
+			// our data is correct?
+			assert.Equal(t, test.expected, ParsePathURL(test.base, test.path))
+		})
+	}
+}
+
+func TestParseUnixSocket(t *testing.T) {
+
+	t.Run("return empty string if a http baseURL", func(t *testing.T) {
+		// Define some vars
+		var baseURL = "http://live.apitest.org"
+		var expected = ""
+
+		// fire up
+		result := ParseUnixSocket(baseURL)
+		// if result equals to expected?
+		assert.Equal(t, expected, result)
+	})
+
+	t.Run("return empty string if a unix baseURL with host", func(t *testing.T) {
+		// Define some vars
+		var baseURL = "unix://live.apitest.org/var/run/docker.sock"
+		var expected = ""
+
+		// fire up
+		result := ParseUnixSocket(baseURL)
+		// if result equals to expected?
+		assert.Equal(t, expected, result)
+	})
+
+	t.Run("return socket path if a unix baseURL", func(t *testing.T) {
+		// Define some vars
+		var baseURL = "unix:///var/run/docker.sock"
+		var expected = "/var/run/docker.sock"
+
+		// fire up
+		result := ParseUnixSocket(baseURL)
+		// if result equals to expected?
+		assert.Equal(t, expected, result)
+	})
+
+	t.Run("return socket path if a http+unix baseURL", func(t *testing.T) {
+		// Define some vars
+		var baseURL = "http+unix://%2Fvar%2Frun%2Fdocker.sock"
+		var expected = "/var/run/docker.sock"
+
+		// fire up
+		result := ParseUnixSocket(baseURL)
+		// if result equals to expected?
+		assert.Equal(t, expected, result)
+	})
+}